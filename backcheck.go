@@ -0,0 +1,149 @@
+// backcheck.go
+// Checking translations by translating them back into mainLang and
+// scoring how close they come to the original.
+package translate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DEFAULT_BACKCHECK_SCORE is the score below which a back translation is
+// flagged.
+const DEFAULT_BACKCHECK_SCORE = 0.5
+
+// BackcheckOptions are the optional settings for XlnsBackcheck.
+type BackcheckOptions struct {
+	// Score is the score, from 0 to 1, below which lines are flagged.
+	// DEFAULT_BACKCHECK_SCORE if 0.
+	Score float64
+	// Langs are the languages to check, all but mainLang and
+	// pseudo-locales if nil.
+	Langs []string
+	// XlnsOptions control the translating as for XlnsAddTranslator.
+	// Options that only apply to writing, such as AllOrNothing and Resume,
+	// are ignored.
+	XlnsOptions *XlnsOptions
+}
+
+// BackcheckLine is a line whose back translation scored below the
+// threshold.
+type BackcheckLine struct {
+	Lang        string  `json:"lang"`
+	Line        int     `json:"line"` // From 1.
+	Source      string  `json:"source"`
+	Translation string  `json:"translation"`
+	Back        string  `json:"back"` // The translation back into mainLang.
+	Score       float64 `json:"score"`
+}
+
+func (bl BackcheckLine) String() string {
+	return fmt.Sprintf("%s:%d: score %.2f %q translated back as %q from %q",
+		bl.Lang, bl.Line, bl.Score, bl.Source, bl.Back, bl.Translation)
+}
+
+// XlnsBackcheck translates each language in wordsDir back into mainLang
+// and returns the lines whose back translation scores below the
+// threshold against the mainLang line, by language and line.  Lines are
+// aligned by meaning as for WordsXlnsMap.  Nothing is written to
+// wordsDir.
+func XlnsBackcheck(ctx context.Context, wordsDir string, translator Translator, mainLang string, options *BackcheckOptions) ([]BackcheckLine, error) {
+	if options == nil {
+		options = &BackcheckOptions{}
+	}
+	threshold := options.Score
+	if threshold == 0 {
+		threshold = DEFAULT_BACKCHECK_SCORE
+	}
+	sources, err := WordsGetWords(wordsDir, mainLang)
+	if err != nil {
+		return nil, err
+	}
+	langs := options.Langs
+	if langs == nil {
+		all, err := WordsLanguages(wordsDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, lang := range all {
+			if lang != mainLang && !IsPseudoLocale(lang) {
+				langs = append(langs, lang)
+			}
+		}
+	}
+	// Runners from the same one share the budget and rate limits.
+	shared := newXlnsRunner(wordsDir, translator, mainLang, sources, options.XlnsOptions)
+	var flagged []BackcheckLine
+	for _, lang := range langs {
+		xm, err := WordsXlnsMap(wordsDir, mainLang, lang)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %s to %s got %v", mainLang, lang, err)
+		}
+		// The lang lines in mainLang order.
+		words := make([]string, len(sources))
+		for i, source := range sources {
+			words[i] = xm[strings.TrimSpace(source)]
+		}
+		// Translating from lang into mainLang.
+		job := &xlnsJob{lang: mainLang, lines: make([]string, len(words))}
+		for i, word := range words {
+			if strings.TrimSpace(word) != "" {
+				job.todo = append(job.todo, i)
+			}
+		}
+		xr := shared.with(translator, lang, words)
+		err = xr.translate(ctx, job)
+		if err != nil {
+			return nil, fmt.Errorf("%s got %w", lang, err)
+		}
+		for _, i := range job.todo {
+			score := BackcheckScore(sources[i], job.lines[i])
+			if score < threshold {
+				flagged = append(flagged, BackcheckLine{
+					Lang:        lang,
+					Line:        i + 1,
+					Source:      sources[i],
+					Translation: words[i],
+					Back:        job.lines[i],
+					Score:       score,
+				})
+			}
+		}
+	}
+	return flagged, nil
+}
+
+// BackcheckScore scores back, a back translation, against source from 0,
+// nothing alike, to 1, the same.  It is the better of the words in common
+// and the Similarity of the letters as back translations often reorder
+// or reword a little.
+func BackcheckScore(source, back string) float64 {
+	score := Similarity(source, back)
+	if overlap := wordOverlap(source, back); overlap > score {
+		score = overlap
+	}
+	return score
+}
+
+// wordOverlap returns the words a and b have in common, ignoring case and
+// punctuation, over their average number of words.
+func wordOverlap(a, b string) float64 {
+	wa := strings.Fields(string(similarityRunes(a)))
+	wb := strings.Fields(string(similarityRunes(b)))
+	if len(wa)+len(wb) == 0 {
+		return 1
+	}
+	counts := make(map[string]int)
+	for _, word := range wa {
+		counts[word]++
+	}
+	common := 0
+	for _, word := range wb {
+		if counts[word] > 0 {
+			counts[word]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(wa)+len(wb))
+}
@@ -0,0 +1,255 @@
+// cache.go
+// A persistent cache of translations to minimize future API calls.
+package translate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const CACHE_FILENAME = ".translate.cache"
+
+// CacheEntry is a cached translation.  Text is the source text followed,
+// after a NUL, by anything else that changed its translation such as the
+// line's note.
+type CacheEntry struct {
+	Engine      string    `json:"engine"`
+	Model       string    `json:"model,omitempty"`
+	Source      string    `json:"source"`
+	Target      string    `json:"target"`
+	Text        string    `json:"text"`
+	Translation string    `json:"translation"`
+	Time        time.Time `json:"time"`
+}
+
+func (ce CacheEntry) key() string {
+	return cacheKey(ce.Engine, ce.Model, ce.Source, ce.Target, ce.Text)
+}
+
+func cacheKey(engine, model, source, target, text string) string {
+	return engine + "\x00" + model + "\x00" + source + "\x00" + target + "\x00" + text
+}
+
+// XlnsCache is an on disk translation cache.  Entries are appended to the
+// cache file as they are added so nothing is lost if a run fails part way.
+// It is safe for concurrent use.
+type XlnsCache struct {
+	filename string
+	mu       sync.Mutex
+	entries  map[string]CacheEntry
+	w        *os.File
+}
+
+// OpenXlnsCache opens, creating if needed, the cache in filename.  Close it
+// when done.
+func OpenXlnsCache(filename string) (*XlnsCache, error) {
+	xc, partial, err := readXlnsCache(filename)
+	if err != nil {
+		return nil, err
+	}
+	xc.w, err = os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening cache got %v", err)
+	}
+	if partial {
+		// Start the next entry on a new line.
+		_, err = xc.w.Write([]byte("\n"))
+		if err != nil {
+			xc.w.Close()
+			return nil, fmt.Errorf("writing cache got %v", err)
+		}
+	}
+	return xc, nil
+}
+
+// ReadXlnsCache opens the cache in filename read only, for estimates.  A
+// missing file is an empty cache and is not created.  Put and Prune fail.
+func ReadXlnsCache(filename string) (*XlnsCache, error) {
+	xc, _, err := readXlnsCache(filename)
+	return xc, err
+}
+
+// readXlnsCache reads the entries in filename, if it exists, and whether
+// the last was only partly written.
+func readXlnsCache(filename string) (*XlnsCache, bool, error) {
+	xc := &XlnsCache{filename: filename, entries: make(map[string]CacheEntry)}
+	r, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return xc, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("opening cache got %v", err)
+	}
+	defer r.Close()
+	partial := false
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) != 0 {
+			var entry CacheEntry
+			// An entry only partly written, by a crash say, is dropped.
+			if json.Unmarshal(line, &entry) == nil {
+				xc.entries[entry.key()] = entry
+			}
+			partial = line[len(line)-1] != '\n'
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("reading cache %s got %v", filename, err)
+		}
+	}
+	return xc, partial, nil
+}
+
+// readOnly returns an error if xc was opened by ReadXlnsCache.
+func (xc *XlnsCache) readOnly() error {
+	if xc.w == nil {
+		return fmt.Errorf("cache %s is read only", xc.filename)
+	}
+	return nil
+}
+
+// Get returns the cached translation of text, if any.
+func (xc *XlnsCache) Get(engine, model, source, target, text string) (string, bool) {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	entry, ok := xc.entries[cacheKey(engine, model, source, target, text)]
+	return entry.Translation, ok
+}
+
+// Put adds the translations of texts to the cache.
+func (xc *XlnsCache) Put(engine, model, source, target string, texts, translations []string) error {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	if err := xc.readOnly(); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	enc := json.NewEncoder(xc.w)
+	for i, text := range texts {
+		entry := CacheEntry{
+			Engine:      engine,
+			Model:       model,
+			Source:      source,
+			Target:      target,
+			Text:        text,
+			Translation: translations[i],
+			Time:        now,
+		}
+		err := enc.Encode(entry)
+		if err != nil {
+			return fmt.Errorf("writing cache got %v", err)
+		}
+		xc.entries[entry.key()] = entry
+	}
+	return nil
+}
+
+// Entries returns the cache entries sorted by engine, model, source,
+// target and text.
+func (xc *XlnsCache) Entries() []CacheEntry {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	keys := make([]string, 0, len(xc.entries))
+	for key := range xc.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]CacheEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = xc.entries[key]
+	}
+	return entries
+}
+
+// Prune removes the entries for which keep returns false and rewrites the
+// cache file.  It returns the number of entries removed.
+func (xc *XlnsCache) Prune(keep func(CacheEntry) bool) (int, error) {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	if err := xc.readOnly(); err != nil {
+		return 0, err
+	}
+	removed := 0
+	for key, entry := range xc.entries {
+		if !keep(entry) {
+			delete(xc.entries, key)
+			removed++
+		}
+	}
+	// Rewrite the file next to the old one then swap them.
+	temp := xc.filename + ".tmp"
+	w, err := os.Create(temp)
+	if err != nil {
+		return 0, fmt.Errorf("creating %s got %v", temp, err)
+	}
+	enc := json.NewEncoder(w)
+	for _, entry := range xc.entries {
+		err = enc.Encode(entry)
+		if err != nil {
+			w.Close()
+			return 0, fmt.Errorf("writing %s got %v", temp, err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		return 0, fmt.Errorf("writing %s got %v", temp, err)
+	}
+	xc.w.Close()
+	err = os.Rename(temp, xc.filename)
+	if err != nil {
+		return 0, fmt.Errorf("replacing cache got %v", err)
+	}
+	xc.w, err = os.OpenFile(xc.filename, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("opening cache got %v", err)
+	}
+	return removed, nil
+}
+
+// Close the cache file, if open for writing.
+func (xc *XlnsCache) Close() error {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	if xc.w == nil {
+		return nil
+	}
+	return xc.w.Close()
+}
+
+// PruneUnused removes the entries whose text is no longer a line of the
+// source language's words file in wordsDir.  It returns the number of
+// entries removed.
+func (xc *XlnsCache) PruneUnused(wordsDir string) (int, error) {
+	langs, err := WordsLanguages(wordsDir)
+	if err != nil {
+		return 0, err
+	}
+	lines := make(map[string]map[string]bool)
+	for _, lang := range langs {
+		words, err := WordsGetWords(wordsDir, lang)
+		if err != nil {
+			return 0, err
+		}
+		lines[lang] = make(map[string]bool)
+		for _, word := range words {
+			lines[lang][word] = true
+		}
+	}
+	return xc.Prune(func(entry CacheEntry) bool {
+		text := entry.Text
+		if i := strings.IndexByte(text, 0); i >= 0 {
+			text = text[:i]
+		}
+		return lines[entry.Source][text]
+	})
+}
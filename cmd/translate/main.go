@@ -1,164 +1,988 @@
-// translate updates meaning ordered words files.
-package main
-
-import (
-	"flag"
-	"fmt"
-	"os"
-
-	xlns "github.com/napcatstudio/translate/v2"
-)
-
-const (
-	USAGE = `translate is a tool for managing meaning ordered words files.
-
-A meaning ordered words file is a file which has words, in one language,
-based on another file in a different language.  The file name specifies the
-language.  The filename must be of the form XX.words, where XX is a ISO-639
-two letter language code.
-
-For instance:
-
-en.words
-Easy to use.
-Easy.
-
-de.words
-Einfach zu gebrauchen.
-Einfach.
-
-fi.words
-Helppokäyttöinen.
-Helppo.
-
-It uses the Google Translate API V3 for translating.
-
-Usage:
-	translate [-words wordsDir] [-credentials credentialsJson] command [arguments]
-
-The commands are:
-	add mainLang newLang [newLang...]
-	  Add a new meaning ordered words file for newLang based on mainLang to
-	  wordsDir.
-	check
-	  Quick wordsDir check.  Does not check translation accuracy just
-	  consistency.  Does not call the Google Translate API.
-	list
-	  List the languages in wordsDir.
-	merge [fromWordsDir]
-	  Updates wordsDir with the words in fromWordsDir.
-	supported displayLang
-	  Show the current Google supported languages in displayLang.
-	update mainLang
-	  Updates all meaning ordered words files in wordsDir.  Effectively,
-	  calls add on each existing non-mainLang language.
-
-Example:
-	translate add en es-419 pl
-
-	Uses Google Translate service credentials in 'credentials.json' translates
-	  'words/en.words' into ES-419 and PL, and adds them as
-	  'words/es-419.words' and 'words/pl.words'.
-
-`
-)
-
-func main() {
-	wordsDir := flag.String(
-		"words", "words", "meaning ordered words directory")
-	credentialsJson := flag.String(
-		"credentials", "credentials.json", "Google service account information")
-
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, USAGE)
-		flag.PrintDefaults()
-	}
-	flag.Parse()
-	err := isDir(*wordsDir)
-	if err != nil {
-		fatal_usage(fmt.Errorf("bad wordsDir (%v)", err))
-	}
-	args := flag.Args()
-	if len(flag.Args()) < 1 {
-		fatal_usage(fmt.Errorf("no command"))
-	}
-
-	// Run command.
-	switch args[0] {
-	case "add":
-		if len(args) < 3 {
-			fatal_usage(fmt.Errorf("no newLang"))
-		}
-		err = xlns.XlnsAdd(*wordsDir, *credentialsJson, args[1], args[2:])
-	case "check":
-		err = xlns.WordsCheck(*wordsDir)
-	case "list":
-		err = listLangs(*wordsDir)
-	case "merge":
-		if len(args) != 2 {
-			fatal_usage(fmt.Errorf("wrong number of arguments"))
-		}
-		err = isDir(args[1])
-		if err != nil {
-			fatal_usage(err)
-		}
-		err = xlns.WordsMerge(*wordsDir, args[1])
-	case "supported":
-		if len(args) != 2 {
-			fatal_usage(fmt.Errorf("bad displayLang"))
-		}
-		err = xlns.XlnsSupported(*credentialsJson, args[1])
-	case "update":
-		if len(args) != 2 {
-			fatal_usage(fmt.Errorf("bad mainLang"))
-		}
-		err = xlns.XlnsUpdate(*wordsDir, *credentialsJson, args[1])
-	}
-	if err != nil {
-		fatal(err)
-	}
-}
-
-func listLangs(wordsDir string) error {
-	langs, err := xlns.WordsLanguages(wordsDir)
-	if err != nil {
-		return err
-	}
-	for _, lang := range langs {
-		fmt.Println(lang)
-	}
-	return nil
-}
-
-func fatal_usage(err error) {
-	fmt.Fprintf(os.Stderr, "error: %v", err)
-	flag.Usage()
-	os.Exit(2)
-}
-
-func fatal(err error) {
-	fmt.Fprintf(os.Stderr, "error: %v", err)
-	os.Exit(2)
-}
-
-func isDir(dir string) error {
-	fileInfo, err := os.Stat(dir)
-	if err != nil {
-		return fmt.Errorf("bad path %s", dir)
-	}
-	if !fileInfo.IsDir() {
-		return fmt.Errorf("%s not directory", dir)
-	}
-	return nil
-}
-
-func isFile(file string) error {
-	fileInfo, err := os.Stat(file)
-	if err != nil {
-		return fmt.Errorf("bad path %s", file)
-	}
-	if fileInfo.IsDir() {
-		return fmt.Errorf("%s not file", file)
-	}
-	return nil
-}
+// translate updates meaning ordered words files.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+const (
+	DEFAULT_CREDENTIALS = "credentials.json"
+	DEEPL_KEY_ENV       = "DEEPL_AUTH_KEY"
+	OPENAI_KEY_ENV      = "OPENAI_API_KEY"
+	USAGE               = `translate is a tool for managing meaning ordered words files.
+
+A meaning ordered words file is a file which has words, in one language,
+based on another file in a different language.  The file name specifies the
+language.  The filename must be of the form XX.words, where XX is a ISO-639
+two letter language code.
+
+For instance:
+
+en.words
+Easy to use.
+Easy.
+
+de.words
+Einfach zu gebrauchen.
+Einfach.
+
+fi.words
+Helppokäyttöinen.
+Helppo.
+
+It uses the Google Translate API V3, or V2 with -api-key, for translating.
+
+Usage:
+	translate [-words wordsDir] [-credentials credentialsJson]
+		[-project projectId] [-api-key apiKey] [-full]
+		[-cache cacheFile] [-nocache] [-workers n] [-rps n] [-cpm n]
+		[-retries n] [-resume] [-atomic] [-dry-run] [-price n]
+		[-budget n] [-protect=false] [-strict] [-glossary glossaryCsv]
+		[-google-glossary glossaryId] [-location location] [-model model]
+		[-mime mimeType] [-endpoint endpoint] [-insecure] [-timeout d]
+		[-proxy proxyUrl] [-record cassette] [-replay cassette]
+		[-engine engine] [-expansion n] [-nomarkers] [-deepl-key key]
+		[-formality formality] [-libre-key key] [-llm-model model]
+		[-llm-key key] [-plugin command] [-format format]
+		[-similarity n] [-all] [-score n] [-confidence n]
+		command [arguments]
+
+The commands are:
+	add mainLang newLang [newLang...]
+	  Add a new meaning ordered words file for newLang based on mainLang to
+	  wordsDir.
+	backcheck mainLang [lang...]
+	  Translate the lines of each lang, or every language, back into
+	  mainLang and report, as -format text or json, the lines whose back
+	  translation scores below -score against the mainLang line.  The
+	  score, from 0 to 1, is the better of the words in common and the
+	  edit distance.  Nothing is written to wordsDir.
+	cache show|prune|export
+	  Show the number of cached translations, prune the translations of
+	  lines no longer in wordsDir, or export the cache as JSON.
+	check [mainLang]
+	  Quick wordsDir check.  Does not check translation accuracy just
+	  consistency.  Does not call the Google Translate API.  With mainLang
+	  also reports lines whose placeholders differ from mainLang's, lines
+	  mostly in the wrong script for their language, such as English left
+	  in zh-CN.words or Simplified characters in zh-TW.words, and with
+	  -glossary lines not using its terms.
+	compare mainLang lang engine engine [engine...]
+	  Translate the mainLang lines into lang with each engine and report
+	  the lines where the engines disagree, their translations less
+	  similar than -similarity, as -format text, json or html.  With -all
+	  every line is reported.  Nothing is written to wordsDir.
+	detect [lang...]
+	  Detect the language of every line of each lang, or every language,
+	  and report, as -format text or json, the lines detected to be in
+	  another language with at least -confidence.  Finds lines left
+	  untranslated or in the wrong language.
+	estimate mainLang [newLang...]
+	  Show, per language, how many segments and characters adding newLang,
+	  or updating if there are none, would send and what it would cost at
+	  -price per million characters.  Cached and up to date lines are not
+	  counted.  Does not call the Google Translate API, need Google
+	  credentials or create the cache.
+	journal
+	  Show the progress of an unfinished add or update in wordsDir.
+	list
+	  List the languages in wordsDir.
+	merge [fromWordsDir]
+	  Updates wordsDir with the words in fromWordsDir.
+	supported displayLang
+	  Show the current Google supported languages in displayLang.
+	update mainLang
+	  Updates all meaning ordered words files in wordsDir.  Only lines of
+	  mainLang that are new or changed since the last translation are
+	  translated, unless -full is given.  Fingerprints of the translated
+	  mainLang lines are kept in XX.fingerprints next to each XX.words.
+
+Translations are cached, by default in wordsDir/.translate.cache, so
+re-adding a language or re-running after a failure does not call the
+Google Translate API again for lines already translated.
+
+Languages are translated -workers at a time.  Requests can be limited with
+-rps and -cpm and those failing on quota or availability are retried
+-retries times, waiting longer each time.  Ctrl-C stops translating,
+languages already finished are written and the rest are left unchanged.
+Progress is journaled in wordsDir/.translate.journal until a run succeeds
+so a failed add or update can be finished with -resume.  With -atomic
+nothing is written unless every language is translated.  Only one translate
+at a time can change wordsDir, see wordsDir/.translate.lock.  With -dry-run
+add and update show their estimate instead of translating.  -budget stops
+add or update before they send more than that many characters.
+
+Placeholders (%s, {name}, $(NAME)) and markup are masked before translating
+and put back afterwards.  Translations whose placeholders differ from their
+source are reported, or fail add and update with -strict.
+
+-glossary gives a CSV file of terms with fixed translations.  Its first row
+has the language codes of the columns, each other row a term in each
+language.  A row with a single term, a brand name say, is never translated.
+Glossary terms are masked before translating and replaced by their
+translations afterwards, unless -google-glossary names a glossary created
+from the same file in Google Cloud for the Google Translate API to apply.
+
+-location chooses the Google Cloud region, global by default.  -model
+chooses a custom AutoML or adaptive model by ID, or a Google model such as
+general/nmt, instead of the default.  Custom models and glossaries need a
+regional location such as us-central1.  -mime text/html translates the
+words as HTML rather than plain text.
+
+The Google Translate API is called with the service account in
+-credentials, credentials.json if it exists, or else the application
+default credentials: GOOGLE_APPLICATION_CREDENTIALS, gcloud auth
+application-default login or those of the environment such as workload
+identity.  The project is that of the credentials unless -project, or
+GOOGLE_CLOUD_PROJECT, gives it.  With -api-key the V2 Basic API is used
+instead, which has no locations, custom models or glossaries.
+
+-endpoint replaces the API endpoint, host:port for V3 or a URL for V2, to
+use a local emulator or fake translation server say.  It applies to every
+engine, or per engine as in
+openai=http://localhost:8080/v1,libretranslate=http://localhost:5000 to
+compare two self-hosted engines.  With -insecure the Google endpoint is
+connected to without TLS or authentication, V3 then needs -project.
+-timeout limits each API call and -proxy gives an HTTP proxy URL to use
+rather than that in HTTPS_PROXY.
+
+-record saves the API requests made, and their answers, to a cassette file.
+-replay answers requests from a cassette without calling the API at all,
+failing on any request not recorded, for repeatable tests of add and update.
+Neither uses the translation cache, so every request is recorded and
+replayed.
+
+Pseudo-locales, languages in the XA or XB region, are made without calling
+any API.  en-XA has accented text made -expansion longer, ar-XB has each
+word mirrored right to left, and both are put in [ ] unless -nomarkers is
+given, to find truncated and hard-coded strings:
+
+	translate add en en-XA ar-XB
+
+-engine pseudo uses only pseudo-localization, needing no credentials.
+
+-engine deepl translates with DeepL using the key in -deepl-key or
+DEEPL_AUTH_KEY.  en and pt are translated as en-US and pt-PT unless en-GB,
+pt-BR and the like are given.  -formality sets the formality, more, less,
+prefer_more or prefer_less, for every language or per language as in
+de=less,fr=prefer_more.  -endpoint, -timeout and -mime apply to DeepL too.
+
+-engine libretranslate translates with a self-hosted LibreTranslate, or
+compatible, server at -endpoint, http://localhost:5000 by default, sending
+-libre-key if the server needs an API key.  No text is sent to Google.
+
+-engine openai translates with a large language model through an OpenAI
+compatible chat completions API, https://api.openai.com/v1 or -endpoint
+such as http://localhost:8080/v1 for llama.cpp or Ollama, using model
+-llm-model and the key in -llm-key or OPENAI_API_KEY.  Lines are sent with
+their notes from wordsDir/mainLang.notes, one note per line of
+mainLang.words and empty lines for none, to tell the model what short
+lines mean:
+
+	en.words   en.notes
+	Open       verb, a menu item
+	Back       button returning to the last page
+
+The -glossary terms are sent with the lines they are in.
+
+-engine plugin translates with the program given by -plugin, such as
+-plugin "inhouse-translate --region eu", which is sent JSON requests, one
+per line, on its standard input and answers each with a line of JSON on
+its standard output.  See the PluginTranslator documentation for the
+requests and answers.  Notes are sent to it like -engine openai.
+
+Example:
+	translate add en es-419 pl
+
+	Uses Google Translate service credentials in 'credentials.json' translates
+	  'words/en.words' into ES-419 and PL, and adds them as
+	  'words/es-419.words' and 'words/pl.words'.
+
+`
+)
+
+// config holds the command line settings for translating.
+type config struct {
+	wordsDir        string
+	credentialsJson string
+	full            bool
+	cacheFile       string
+	noCache         bool
+	workers         int
+	rps             float64
+	cpm             int
+	retries         int
+	resume          bool
+	atomic          bool
+	dryRun          bool
+	price           float64
+	budget          int
+	protect         bool
+	strict          bool
+	glossaryCsv     string
+	googleGlossary  string
+	apiKey          string
+	engine          string
+	deeplKey        string
+	formality       string
+	libreKey        string
+	llm             xlns.LLMOptions
+	plugin          string
+	format          string
+	similarity      float64
+	all             bool
+	score           float64
+	confidence      float64
+	pseudo          xlns.PseudoOptions
+	record          string
+	replay          string
+	endpoints       string
+	google          xlns.GoogleOptions
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.wordsDir,
+		"words", "words", "meaning ordered words directory")
+	flag.StringVar(&cfg.credentialsJson,
+		"credentials", "", "Google service account information (default "+
+			DEFAULT_CREDENTIALS+" if it exists, otherwise the application default credentials)")
+	flag.StringVar(&cfg.google.ProjectID,
+		"project", "", "Google Cloud project ID (default the credentials' project)")
+	flag.StringVar(&cfg.apiKey,
+		"api-key", "", "Google API key, translating with the V2 Basic API")
+	flag.BoolVar(&cfg.full,
+		"full", false, "update retranslates every line, not just new or changed ones")
+	flag.StringVar(&cfg.cacheFile,
+		"cache", "", "translation cache file (default wordsDir/"+xlns.CACHE_FILENAME+")")
+	flag.BoolVar(&cfg.noCache,
+		"nocache", false, "do not use the translation cache")
+	flag.IntVar(&cfg.workers,
+		"workers", 4, "number of languages translated at once")
+	flag.Float64Var(&cfg.rps,
+		"rps", 0, "limit on translation requests per second (0 no limit)")
+	flag.IntVar(&cfg.cpm,
+		"cpm", 0, "limit on characters translated per minute (0 no limit)")
+	flag.IntVar(&cfg.retries,
+		"retries", 5, "retries of requests failing on quota or availability")
+	flag.BoolVar(&cfg.resume,
+		"resume", false, "resume the unfinished add or update in wordsDir")
+	flag.BoolVar(&cfg.atomic,
+		"atomic", false, "write the languages only if all are translated")
+	flag.BoolVar(&cfg.dryRun,
+		"dry-run", false, "add and update only estimate what they would send")
+	flag.Float64Var(&cfg.price,
+		"price", xlns.DEFAULT_PRICE, "price per million characters for estimates")
+	flag.IntVar(&cfg.budget,
+		"budget", 0, "most characters add or update may send (0 no limit)")
+	flag.BoolVar(&cfg.protect,
+		"protect", true, "protect placeholders and markup from translation")
+	flag.BoolVar(&cfg.strict,
+		"strict", false, "fail when a translation's placeholders differ from its source")
+	flag.StringVar(&cfg.glossaryCsv,
+		"glossary", "", "CSV file of terms with fixed translations")
+	flag.StringVar(&cfg.googleGlossary,
+		"google-glossary", "", "ID of a Google Cloud glossary to translate with")
+	flag.StringVar(&cfg.google.Location,
+		"location", xlns.DEFAULT_LOCATION, "Google Cloud location")
+	flag.StringVar(&cfg.google.Model,
+		"model", "", "Google translation model (default the general model)")
+	flag.StringVar(&cfg.google.MimeType,
+		"mime", xlns.MIME_TEXT, "mime type of the words, "+xlns.MIME_TEXT+" or "+xlns.MIME_HTML)
+	flag.StringVar(&cfg.endpoints,
+		"endpoint", "", "API endpoint for every engine, or per engine as in openai=URL,libretranslate=URL")
+	flag.BoolVar(&cfg.google.Insecure,
+		"insecure", false, "connect to the Google -endpoint without TLS or authentication")
+	flag.DurationVar(&cfg.google.Timeout,
+		"timeout", 0, "time limit on each API call (0 no limit)")
+	flag.StringVar(&cfg.google.Proxy,
+		"proxy", "", "HTTP proxy URL (default from HTTPS_PROXY)")
+	flag.StringVar(&cfg.engine,
+		"engine", "google", "translation engine, google, deepl, libretranslate, openai, plugin or pseudo")
+	flag.StringVar(&cfg.deeplKey,
+		"deepl-key", "", "DeepL authentication key (default from "+DEEPL_KEY_ENV+")")
+	flag.StringVar(&cfg.formality,
+		"formality", "", "DeepL formality, for all languages or as lang=formality,...")
+	flag.StringVar(&cfg.libreKey,
+		"libre-key", "", "LibreTranslate API key, if the server needs one")
+	flag.StringVar(&cfg.llm.Model,
+		"llm-model", xlns.LLM_MODEL, "model for -engine openai")
+	flag.StringVar(&cfg.llm.APIKey,
+		"llm-key", "", "API key for -engine openai (default from "+OPENAI_KEY_ENV+")")
+	flag.StringVar(&cfg.plugin,
+		"plugin", "", "program, with its arguments, for -engine plugin")
+	flag.StringVar(&cfg.format,
+		"format", "text", "compare, backcheck and detect report format, text, json or html")
+	flag.Float64Var(&cfg.similarity,
+		"similarity", xlns.DEFAULT_SIMILARITY, "similarity, 0 to 1, below which compared translations disagree")
+	flag.BoolVar(&cfg.all,
+		"all", false, "compare reports every line, not just those that disagree")
+	flag.Float64Var(&cfg.score,
+		"score", xlns.DEFAULT_BACKCHECK_SCORE, "score, 0 to 1, below which backcheck reports a line")
+	flag.Float64Var(&cfg.confidence,
+		"confidence", 0, "least confidence, 0 to 1, of a detection detect reports")
+	flag.Float64Var(&cfg.pseudo.Expansion,
+		"expansion", xlns.PSEUDO_EXPANSION, "how much longer pseudo-localized text is made")
+	flag.BoolVar(&cfg.pseudo.NoMarkers,
+		"nomarkers", false, "leave out the [ ] around pseudo-localized text")
+	flag.StringVar(&cfg.record,
+		"record", "", "cassette file to record API requests to")
+	flag.StringVar(&cfg.replay,
+		"replay", "", "cassette file to answer API requests from")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s", USAGE)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	err := isDir(cfg.wordsDir)
+	if err != nil {
+		fatal_usage(fmt.Errorf("bad wordsDir (%v)", err))
+	}
+	args := flag.Args()
+	if len(flag.Args()) < 1 {
+		fatal_usage(fmt.Errorf("no command"))
+	}
+
+	// Ctrl-C stops translating leaving the languages done so far written.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Run command.
+	switch args[0] {
+	case "add":
+		if len(args) < 3 {
+			fatal_usage(fmt.Errorf("no newLang"))
+		}
+		err = cfg.run(ctx, func(t xlns.Translator, options *xlns.XlnsOptions) error {
+			err := cfg.notes(options, args[1])
+			if err != nil {
+				return err
+			}
+			if cfg.dryRun {
+				return cfg.estimate(t, options, args[1], args[2:])
+			}
+			return xlns.XlnsAddContext(ctx, cfg.wordsDir, t, args[1], args[2:], options)
+		})
+	case "backcheck":
+		if len(args) < 2 {
+			fatal_usage(fmt.Errorf("no mainLang"))
+		}
+		err = cfg.run(ctx, func(t xlns.Translator, options *xlns.XlnsOptions) error {
+			return cfg.backcheck(ctx, t, options, args[1], args[2:])
+		})
+	case "cache":
+		if len(args) != 2 {
+			fatal_usage(fmt.Errorf("bad cache command"))
+		}
+		err = cfg.cacheCommand(args[1])
+	case "check":
+		if len(args) > 2 {
+			fatal_usage(fmt.Errorf("wrong number of arguments"))
+		}
+		err = xlns.WordsCheck(cfg.wordsDir)
+		if err == nil && len(args) == 2 {
+			err = cfg.checkLines(args[1])
+		}
+	case "compare":
+		if len(args) < 5 {
+			fatal_usage(fmt.Errorf("compare needs mainLang, lang and at least 2 engines"))
+		}
+		err = cfg.compare(ctx, args[1], args[2], args[3:])
+	case "detect":
+		err = cfg.run(ctx, func(t xlns.Translator, options *xlns.XlnsOptions) error {
+			return cfg.detect(ctx, t, options, args[1:])
+		})
+	case "estimate":
+		if len(args) < 2 {
+			fatal_usage(fmt.Errorf("no mainLang"))
+		}
+		cfg.dryRun = true
+		err = cfg.run(ctx, func(t xlns.Translator, options *xlns.XlnsOptions) error {
+			err := cfg.notes(options, args[1])
+			if err != nil {
+				return err
+			}
+			return cfg.estimate(t, options, args[1], args[2:])
+		})
+	case "journal":
+		err = showJournal(cfg.wordsDir)
+	case "list":
+		err = listLangs(cfg.wordsDir)
+	case "merge":
+		if len(args) != 2 {
+			fatal_usage(fmt.Errorf("wrong number of arguments"))
+		}
+		err = isDir(args[1])
+		if err != nil {
+			fatal_usage(err)
+		}
+		err = xlns.WordsMerge(cfg.wordsDir, args[1])
+	case "supported":
+		if len(args) != 2 {
+			fatal_usage(fmt.Errorf("bad displayLang"))
+		}
+		err = cfg.run(ctx, func(t xlns.Translator, options *xlns.XlnsOptions) error {
+			return xlns.XlnsSupportedContext(ctx, t, args[1])
+		})
+	case "update":
+		if len(args) != 2 {
+			fatal_usage(fmt.Errorf("bad mainLang"))
+		}
+		err = cfg.run(ctx, func(t xlns.Translator, options *xlns.XlnsOptions) error {
+			err := cfg.notes(options, args[1])
+			if err != nil {
+				return err
+			}
+			if cfg.dryRun {
+				return cfg.estimate(t, options, args[1], nil)
+			}
+			return xlns.XlnsUpdateContext(ctx, cfg.wordsDir, t, args[1], options)
+		})
+	default:
+		fatal_usage(fmt.Errorf("unknown command %s", args[0]))
+	}
+	if errors.Is(err, context.Canceled) {
+		fatal(fmt.Errorf("interrupted, unfinished languages left unchanged"))
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// run runs fn with a Translator and XlnsOptions from the configuration.
+// Everything is closed afterwards.
+func (cfg *config) run(ctx context.Context, fn func(xlns.Translator, *xlns.XlnsOptions) error) error {
+	options, closeOptions, err := cfg.xlnsOptions()
+	if err != nil {
+		return err
+	}
+	defer closeOptions()
+	translator, err := cfg.translator(ctx)
+	if err != nil {
+		return err
+	}
+	defer translator.Close()
+	return fn(translator, options)
+}
+
+// xlnsOptions returns the XlnsOptions from the configuration and a
+// function closing them.
+func (cfg *config) xlnsOptions() (*xlns.XlnsOptions, func(), error) {
+	options := &xlns.XlnsOptions{
+		Full:                cfg.full,
+		Workers:             cfg.workers,
+		RequestsPerSecond:   cfg.rps,
+		CharactersPerMinute: cfg.cpm,
+		Retries:             cfg.retries,
+		Resume:              cfg.resume,
+		AllOrNothing:        cfg.atomic,
+		Budget:              cfg.budget,
+		Protect:             cfg.protect,
+		StrictPlaceholders:  cfg.strict,
+		Warn:                warn,
+	}
+	if cfg.glossaryCsv != "" {
+		glossary, err := xlns.ReadGlossary(cfg.glossaryCsv)
+		if err != nil {
+			return nil, nil, err
+		}
+		options.Glossary = glossary
+	}
+	// Cached translations would never reach the cassette.
+	if cfg.noCache || cfg.record != "" || cfg.replay != "" {
+		return options, func() {}, nil
+	}
+	cache, err := cfg.openCache()
+	if err != nil {
+		return nil, nil, err
+	}
+	options.Cache = cache
+	return options, func() { cache.Close() }, nil
+}
+
+// translator returns the Translator for the configuration, recording or
+// replaying if asked.
+func (cfg *config) translator(ctx context.Context) (xlns.Translator, error) {
+	if cfg.replay != "" {
+		return xlns.NewReplayTranslator(cfg.replay)
+	}
+	translator, err := cfg.engineTranslator(ctx, cfg.engine)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.record != "" {
+		return xlns.NewRecordTranslator(translator, cfg.record), nil
+	}
+	return translator, nil
+}
+
+// engineTranslator returns the Translator for engine.  Pseudo-locales are
+// always pseudo-localized.
+func (cfg *config) engineTranslator(ctx context.Context, engine string) (xlns.Translator, error) {
+	pseudo := xlns.NewPseudoTranslator(&cfg.pseudo)
+	switch engine {
+	case "google":
+		translator, err := cfg.googleTranslator(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return xlns.NewPseudoRouter(translator, pseudo), nil
+	case "deepl":
+		translator, err := cfg.deeplTranslator()
+		if err != nil {
+			return nil, err
+		}
+		return xlns.NewPseudoRouter(translator, pseudo), nil
+	case "libretranslate":
+		translator, err := xlns.NewLibreTranslator(&xlns.LibreOptions{
+			Endpoint: cfg.endpoint("libretranslate"),
+			APIKey:   cfg.libreKey,
+			MimeType: cfg.google.MimeType,
+			Timeout:  cfg.google.Timeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return xlns.NewPseudoRouter(translator, pseudo), nil
+	case "openai":
+		translator, err := cfg.llmTranslator()
+		if err != nil {
+			return nil, err
+		}
+		return xlns.NewPseudoRouter(translator, pseudo), nil
+	case "plugin":
+		command := strings.Fields(cfg.plugin)
+		if len(command) == 0 {
+			return nil, fmt.Errorf("no -plugin program for -engine plugin")
+		}
+		translator, err := xlns.NewPluginTranslator(ctx, command[0], command[1:]...)
+		if err != nil {
+			return nil, err
+		}
+		return xlns.NewPseudoRouter(translator, pseudo), nil
+	case "pseudo":
+		return pseudo, nil
+	}
+	return nil, fmt.Errorf("unknown engine %s", engine)
+}
+
+// deeplTranslator returns the DeepL Translator for the configuration.
+func (cfg *config) deeplTranslator() (xlns.Translator, error) {
+	authKey := cfg.deeplKey
+	if authKey == "" {
+		authKey = os.Getenv(DEEPL_KEY_ENV)
+	}
+	if authKey == "" {
+		return nil, fmt.Errorf("no DeepL key, give -deepl-key or set %s", DEEPL_KEY_ENV)
+	}
+	options := &xlns.DeepLOptions{
+		Endpoint:  cfg.endpoint("deepl"),
+		Formality: make(map[string]string),
+		MimeType:  cfg.google.MimeType,
+		Timeout:   cfg.google.Timeout,
+	}
+	// A list of lang=formality, or just formality for every language.
+	for _, setting := range strings.Split(cfg.formality, ",") {
+		if setting == "" {
+			continue
+		}
+		lang, formality := "*", setting
+		if i := strings.Index(setting, "="); i >= 0 {
+			lang, formality = setting[:i], setting[i+1:]
+		}
+		options.Formality[lang] = formality
+	}
+	return xlns.NewDeepLTranslator(authKey, options)
+}
+
+// llmTranslator returns the large language model Translator for the
+// configuration.
+func (cfg *config) llmTranslator() (xlns.Translator, error) {
+	options := cfg.llm
+	if options.APIKey == "" {
+		options.APIKey = os.Getenv(OPENAI_KEY_ENV)
+	}
+	options.Endpoint = cfg.endpoint("openai")
+	options.MimeType = cfg.google.MimeType
+	options.Timeout = cfg.google.Timeout
+	if cfg.glossaryCsv != "" {
+		glossary, err := xlns.ReadGlossary(cfg.glossaryCsv)
+		if err != nil {
+			return nil, err
+		}
+		options.Glossary = glossary
+	}
+	return xlns.NewLLMTranslator(&options)
+}
+
+// endpoint returns the -endpoint for engine, "" for its default.
+func (cfg *config) endpoint(engine string) string {
+	endpoint := ""
+	// A list of engine=endpoint, or just an endpoint for every engine.
+	// Endpoints may have = in their query but engines have no : or /.
+	for _, setting := range strings.Split(cfg.endpoints, ",") {
+		i := strings.Index(setting, "=")
+		if i >= 0 && !strings.ContainsAny(setting[:i], ":/") {
+			if setting[:i] == engine {
+				return setting[i+1:]
+			}
+			continue
+		}
+		if setting != "" {
+			endpoint = setting
+		}
+	}
+	return endpoint
+}
+
+// notes sets options.Notes from the mainLang notes if any of engines, or
+// -engine if none are given, use them.
+func (cfg *config) notes(options *xlns.XlnsOptions, mainLang string, engines ...string) error {
+	if len(engines) == 0 {
+		engines = []string{cfg.engine}
+	}
+	uses := false
+	for _, engine := range engines {
+		uses = uses || engine == "openai" || engine == "plugin"
+	}
+	if !uses {
+		return nil
+	}
+	notes, err := xlns.WordsGetNotes(cfg.wordsDir, mainLang)
+	if err != nil {
+		return err
+	}
+	options.Notes = notes
+	return nil
+}
+
+// googleTranslator returns the Google Translator for the configuration, V2
+// with an API key or otherwise V3.
+func (cfg *config) googleTranslator(ctx context.Context) (xlns.Translator, error) {
+	options := cfg.google
+	options.Endpoint = cfg.endpoint("google")
+	if cfg.apiKey != "" {
+		if cfg.googleGlossary != "" {
+			return nil, fmt.Errorf("-google-glossary needs the V3 API, not -api-key")
+		}
+		return xlns.NewGoogleV2Translator(ctx, cfg.apiKey, &options)
+	}
+	credentialsJson := cfg.credentialsJson
+	if credentialsJson == "" && isFile(DEFAULT_CREDENTIALS) == nil {
+		credentialsJson = DEFAULT_CREDENTIALS
+	}
+	newTranslator := xlns.NewGoogleTranslatorOptions
+	if cfg.dryRun {
+		// Estimates only need the names and limits, not credentials.
+		newTranslator = xlns.NewGoogleNamer
+	}
+	translator, err := newTranslator(ctx, credentialsJson, &options)
+	if err != nil {
+		return nil, err
+	}
+	translator.SetGlossary(cfg.googleGlossary)
+	return translator, nil
+}
+
+// backcheck prints the lines of langs, all if none, whose translation back
+// into mainLang is not close to the mainLang line.
+func (cfg *config) backcheck(ctx context.Context, t xlns.Translator, options *xlns.XlnsOptions, mainLang string, langs []string) error {
+	if cfg.format != "text" && cfg.format != "json" {
+		return fmt.Errorf("unknown format %s", cfg.format)
+	}
+	backcheckOptions := &xlns.BackcheckOptions{
+		Score:       cfg.score,
+		XlnsOptions: options,
+	}
+	if len(langs) != 0 {
+		backcheckOptions.Langs = langs
+	}
+	flagged, err := xlns.XlnsBackcheck(ctx, cfg.wordsDir, t, mainLang, backcheckOptions)
+	if err != nil {
+		return err
+	}
+	lines := make([]fmt.Stringer, len(flagged))
+	for i, line := range flagged {
+		lines[i] = line
+	}
+	err = cfg.printLines(flagged, lines)
+	if err == nil && len(flagged) != 0 {
+		err = fmt.Errorf("%d lines below score %.2f", len(flagged), cfg.score)
+	}
+	return err
+}
+
+// detect prints the lines of langs, all if none, detected to be in
+// another language.
+func (cfg *config) detect(ctx context.Context, t xlns.Translator, options *xlns.XlnsOptions, langs []string) error {
+	if cfg.format != "text" && cfg.format != "json" {
+		return fmt.Errorf("unknown format %s", cfg.format)
+	}
+	detectOptions := &xlns.DetectOptions{
+		Confidence:  float32(cfg.confidence),
+		XlnsOptions: options,
+	}
+	if len(langs) != 0 {
+		detectOptions.Langs = langs
+	}
+	found, err := xlns.XlnsDetect(ctx, cfg.wordsDir, t, detectOptions)
+	if err != nil {
+		return err
+	}
+	lines := make([]fmt.Stringer, len(found))
+	for i, line := range found {
+		lines[i] = line
+	}
+	err = cfg.printLines(found, lines)
+	if err == nil && len(found) != 0 {
+		err = fmt.Errorf("%d lines in the wrong language", len(found))
+	}
+	return err
+}
+
+// printLines prints report as JSON with -format json or otherwise lines,
+// one per line.
+func (cfg *config) printLines(report interface{}, lines []fmt.Stringer) error {
+	if cfg.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// compare prints the report of comparing the translations of mainLang
+// into lang by engines.
+func (cfg *config) compare(ctx context.Context, mainLang, lang string, engines []string) error {
+	var write func(*xlns.CompareReport) error
+	switch cfg.format {
+	case "text":
+		write = func(report *xlns.CompareReport) error { return report.WriteText(os.Stdout) }
+	case "json":
+		write = func(report *xlns.CompareReport) error { return report.WriteJSON(os.Stdout) }
+	case "html":
+		write = func(report *xlns.CompareReport) error { return report.WriteHTML(os.Stdout) }
+	default:
+		return fmt.Errorf("unknown format %s", cfg.format)
+	}
+	options, closeOptions, err := cfg.xlnsOptions()
+	if err != nil {
+		return err
+	}
+	defer closeOptions()
+	err = cfg.notes(options, mainLang, engines...)
+	if err != nil {
+		return err
+	}
+	var translators []xlns.Translator
+	for _, engine := range engines {
+		translator, err := cfg.engineTranslator(ctx, engine)
+		if err != nil {
+			return err
+		}
+		defer translator.Close()
+		translators = append(translators, translator)
+	}
+	report, err := xlns.XlnsCompare(ctx, cfg.wordsDir, translators, mainLang, lang,
+		&xlns.CompareOptions{
+			Similarity:  cfg.similarity,
+			All:         cfg.all,
+			XlnsOptions: options,
+		})
+	if err != nil {
+		return err
+	}
+	return write(report)
+}
+
+// estimate prints what adding newLangs, or updating if there are none,
+// would send to the translator and what it would cost.
+func (cfg *config) estimate(t xlns.Translator, options *xlns.XlnsOptions, mainLang string, newLangs []string) error {
+	var xe *xlns.XlnsEstimate
+	var err error
+	if len(newLangs) == 0 {
+		xe, err = xlns.XlnsEstimateUpdate(cfg.wordsDir, t, mainLang, options)
+	} else {
+		xe, err = xlns.XlnsEstimateAdd(cfg.wordsDir, t, mainLang, newLangs, options)
+	}
+	if err != nil {
+		return err
+	}
+	for _, le := range xe.Langs {
+		fmt.Printf("\t%s %d segments %d characters in %d requests (%d cached, %d kept)\n",
+			le.Lang, le.Segments, le.Characters, le.Requests, le.Cached, le.Kept)
+	}
+	fmt.Printf("total %d segments %d characters in %d requests costing about %.2f at %.2f per million characters\n",
+		xe.Segments, xe.Characters, xe.Requests, xe.Cost(cfg.price), cfg.price)
+	return nil
+}
+
+// openCache opens the translation cache, read only for a dry run.
+func (cfg *config) openCache() (*xlns.XlnsCache, error) {
+	cacheFile := cfg.cacheFile
+	if cacheFile == "" {
+		cacheFile = path.Join(cfg.wordsDir, xlns.CACHE_FILENAME)
+	}
+	if cfg.dryRun {
+		return xlns.ReadXlnsCache(cacheFile)
+	}
+	return xlns.OpenXlnsCache(cacheFile)
+}
+
+// cacheCommand runs the cache sub-commands.
+func (cfg *config) cacheCommand(command string) error {
+	cache, err := cfg.openCache()
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+	switch command {
+	case "show":
+		counts := make(map[string]int)
+		var keys []string
+		for _, entry := range cache.Entries() {
+			key := fmt.Sprintf("%s %s %s>%s", entry.Engine, entry.Model, entry.Source, entry.Target)
+			if counts[key] == 0 {
+				keys = append(keys, key)
+			}
+			counts[key]++
+		}
+		for _, key := range keys {
+			fmt.Printf("\t%s %d\n", key, counts[key])
+		}
+	case "prune":
+		removed, err := cache.PruneUnused(cfg.wordsDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("removed %d unused translations\n", removed)
+	case "export":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cache.Entries())
+	default:
+		return fmt.Errorf("unknown cache command %s", command)
+	}
+	return nil
+}
+
+// checkLines reports lines in wordsDir with problems compared to mainLang.
+func (cfg *config) checkLines(mainLang string) error {
+	problems, err := xlns.WordsCheckPlaceholders(cfg.wordsDir, mainLang)
+	if err != nil {
+		return err
+	}
+	more, err := xlns.WordsCheckScripts(cfg.wordsDir, mainLang)
+	if err != nil {
+		return err
+	}
+	problems = append(problems, more...)
+	if cfg.glossaryCsv != "" {
+		glossary, err := xlns.ReadGlossary(cfg.glossaryCsv)
+		if err != nil {
+			return err
+		}
+		more, err := xlns.WordsCheckGlossary(cfg.wordsDir, mainLang, glossary)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, more...)
+	}
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("%d problems", len(problems))
+	}
+	return nil
+}
+
+// warn reports problems found while translating.
+func warn(problem xlns.WordsProblem) {
+	fmt.Fprintf(os.Stderr, "warning: %v\n", problem)
+}
+
+func showJournal(wordsDir string) error {
+	status, err := xlns.XlnsJournalStatus(wordsDir)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		fmt.Println("no unfinished run")
+		return nil
+	}
+	fmt.Printf("%s %s\n", status.Command, status.MainLang)
+	done := make(map[string]bool)
+	for _, lang := range status.Done {
+		done[lang] = true
+	}
+	for _, lang := range status.Langs {
+		switch {
+		case done[lang]:
+			fmt.Printf("\t%s done\n", lang)
+		case status.Lines[lang] > 0:
+			fmt.Printf("\t%s %d lines translated\n", lang, status.Lines[lang])
+		default:
+			fmt.Printf("\t%s not started\n", lang)
+		}
+	}
+	return nil
+}
+
+func listLangs(wordsDir string) error {
+	langs, err := xlns.WordsLanguages(wordsDir)
+	if err != nil {
+		return err
+	}
+	for _, lang := range langs {
+		fmt.Println(lang)
+	}
+	return nil
+}
+
+func fatal_usage(err error) {
+	fmt.Fprintf(os.Stderr, "error: %v", err)
+	flag.Usage()
+	os.Exit(2)
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "error: %v", err)
+	os.Exit(2)
+}
+
+func isDir(dir string) error {
+	fileInfo, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("bad path %s", dir)
+	}
+	if !fileInfo.IsDir() {
+		return fmt.Errorf("%s not directory", dir)
+	}
+	return nil
+}
+
+func isFile(file string) error {
+	fileInfo, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("bad path %s", file)
+	}
+	if fileInfo.IsDir() {
+		return fmt.Errorf("%s not file", file)
+	}
+	return nil
+}
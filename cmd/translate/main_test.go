@@ -0,0 +1,95 @@
+// Test the command's configuration.
+package main
+
+import (
+	"context"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+// add adds lang to wordsDir with the configuration.
+func (cfg *config) add(t *testing.T, lang string) {
+	err := cfg.run(context.Background(), func(tr xlns.Translator, options *xlns.XlnsOptions) error {
+		return xlns.XlnsAddContext(context.Background(), cfg.wordsDir, tr, "en", []string{lang}, options)
+	})
+	if err != nil {
+		t.Fatalf("add %s got %v", lang, err)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	words := []string{"Open", "Save file"}
+	cassette := path.Join(t.TempDir(), "cassette.json")
+	cfg := &config{wordsDir: t.TempDir(), engine: "pseudo", workers: 1}
+	err := xlns.WordsWriteWords(cfg.wordsDir, "en", words)
+	if err != nil {
+		t.Fatalf("WordsWriteWords got %v", err)
+	}
+	// Cached translations are still recorded.
+	cfg.add(t, "en-XA")
+	os.Remove(xlns.WordsFilename(cfg.wordsDir, "en-XA"))
+	cfg.record = cassette
+	cfg.add(t, "en-XA")
+	recorded, _ := xlns.WordsGetWords(cfg.wordsDir, "en-XA")
+	c, err := xlns.ReadCassette(cassette)
+	if err != nil || len(c.Requests) == 0 {
+		t.Fatalf("cassette got %v %v", c, err)
+	}
+	// And replayed in a new words directory.
+	cfg = &config{wordsDir: t.TempDir(), engine: "google", workers: 1, replay: cassette}
+	xlns.WordsWriteWords(cfg.wordsDir, "en", words)
+	cfg.add(t, "en-XA")
+	replayed, _ := xlns.WordsGetWords(cfg.wordsDir, "en-XA")
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed %q expected %q", replayed, recorded)
+	}
+	if _, err := os.Stat(path.Join(cfg.wordsDir, xlns.CACHE_FILENAME)); err == nil {
+		t.Errorf("replay made a cache file")
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	// No credentials to be found.
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path.Join(t.TempDir(), "missing.json"))
+	cfg := &config{wordsDir: t.TempDir(), engine: "google", dryRun: true}
+	xlns.WordsWriteWords(cfg.wordsDir, "en", []string{"Open", "Save file"})
+	err := cfg.run(context.Background(), func(tr xlns.Translator, options *xlns.XlnsOptions) error {
+		xe, err := xlns.XlnsEstimateAdd(cfg.wordsDir, tr, "en", []string{"de"}, options)
+		if err == nil && xe.Characters != len("OpenSave file") {
+			t.Errorf("estimated %d characters", xe.Characters)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("estimate got %v", err)
+	}
+	if _, err := os.Stat(path.Join(cfg.wordsDir, xlns.CACHE_FILENAME)); err == nil {
+		t.Errorf("estimate made a cache file")
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	var tests = []struct {
+		endpoints, engine, endpoint string
+	}{
+		{"", "google", ""},
+		{"localhost:8443", "google", "localhost:8443"},
+		{"localhost:8443", "openai", "localhost:8443"},
+		{"openai=http://localhost:8080/v1,libretranslate=http://localhost:5000", "openai", "http://localhost:8080/v1"},
+		{"openai=http://localhost:8080/v1,libretranslate=http://localhost:5000", "libretranslate", "http://localhost:5000"},
+		{"openai=http://localhost:8080/v1,libretranslate=http://localhost:5000", "google", ""},
+		{"http://localhost/?key=1,deepl=http://localhost:3000", "libretranslate", "http://localhost/?key=1"},
+		{"http://localhost/?key=1,deepl=http://localhost:3000", "deepl", "http://localhost:3000"},
+	}
+	for _, test := range tests {
+		cfg := &config{endpoints: test.endpoints}
+		if endpoint := cfg.endpoint(test.engine); endpoint != test.endpoint {
+			t.Errorf("endpoint(%s) of %s got %q expected %q",
+				test.engine, test.endpoints, endpoint, test.endpoint)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// compare.go
+// Comparing the translations of several engines to find the lines they
+// disagree on, which are the ones worth a reviewer's time.
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DEFAULT_SIMILARITY is the similarity below which engines disagree.
+const DEFAULT_SIMILARITY = 0.6
+
+// CompareOptions are the optional settings for XlnsCompare.
+type CompareOptions struct {
+	// Similarity is the similarity, from 0 to 1, below which the
+	// translations of a line disagree.  DEFAULT_SIMILARITY if 0.
+	Similarity float64
+	// All reports every line, not just those that disagree.
+	All bool
+	// XlnsOptions control the translating as for XlnsAddTranslator.
+	// Options that only apply to writing, such as AllOrNothing and Resume,
+	// are ignored.
+	XlnsOptions *XlnsOptions
+}
+
+// CompareReport is the result of comparing engines.
+type CompareReport struct {
+	MainLang   string        `json:"mainLang"`
+	Lang       string        `json:"lang"`
+	Engines    []string      `json:"engines"`
+	Similarity float64       `json:"similarity"` // Below which lines disagree.
+	Compared   int           `json:"compared"`   // Lines compared.
+	Disagree   int           `json:"disagree"`   // Lines disagreeing.
+	Lines      []CompareLine `json:"lines"`
+}
+
+// CompareLine is a line translated by each engine.
+type CompareLine struct {
+	Line         int      `json:"line"` // From 1.
+	Source       string   `json:"source"`
+	Translations []string `json:"translations"` // In Engines order.
+	Similarity   float64  `json:"similarity"`   // Of the least similar pair.
+	Disagree     bool     `json:"disagree"`
+}
+
+// XlnsCompare translates the mainLang lines in wordsDir into lang with
+// each of translators and reports the lines whose translations disagree,
+// least similar first.  Nothing is written to wordsDir.
+func XlnsCompare(ctx context.Context, wordsDir string, translators []Translator, mainLang, lang string, options *CompareOptions) (*CompareReport, error) {
+	if len(translators) < 2 {
+		return nil, fmt.Errorf("compare needs at least 2 engines got %d", len(translators))
+	}
+	if options == nil {
+		options = &CompareOptions{}
+	}
+	words, err := WordsGetWords(wordsDir, mainLang)
+	if err != nil {
+		return nil, err
+	}
+	report := &CompareReport{
+		MainLang:   mainLang,
+		Lang:       lang,
+		Similarity: options.Similarity,
+		Compared:   len(words),
+	}
+	if report.Similarity == 0 {
+		report.Similarity = DEFAULT_SIMILARITY
+	}
+	var translations [][]string
+	var shared *xlnsRunner // Spending from one budget for every engine.
+	for _, translator := range translators {
+		engine, model := TranslatorEngine(translator)
+		if model != "" {
+			engine += " " + model
+		}
+		report.Engines = append(report.Engines, engine)
+		job := &xlnsJob{lang: lang, lines: make([]string, len(words))}
+		for i := range words {
+			job.todo = append(job.todo, i)
+		}
+		if shared == nil {
+			shared = newXlnsRunner(wordsDir, translator, mainLang, words, options.XlnsOptions)
+		}
+		xr := shared.with(translator, mainLang, words)
+		err = xr.checkNotes()
+		if err == nil {
+			err = xr.translate(ctx, job)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s got %w", engine, err)
+		}
+		translations = append(translations, job.lines)
+	}
+	for i, source := range words {
+		line := CompareLine{Line: i + 1, Source: source, Similarity: 1}
+		for _, lines := range translations {
+			line.Translations = append(line.Translations, lines[i])
+		}
+		for a := 0; a < len(line.Translations); a++ {
+			for b := a + 1; b < len(line.Translations); b++ {
+				similarity := Similarity(line.Translations[a], line.Translations[b])
+				if similarity < line.Similarity {
+					line.Similarity = similarity
+				}
+			}
+		}
+		line.Disagree = line.Similarity < report.Similarity
+		if line.Disagree {
+			report.Disagree++
+		}
+		if line.Disagree || options.All {
+			report.Lines = append(report.Lines, line)
+		}
+	}
+	// Least similar first.
+	sort.SliceStable(report.Lines, func(i, j int) bool {
+		return report.Lines[i].Similarity < report.Lines[j].Similarity
+	})
+	return report, nil
+}
+
+// Similarity returns how alike a and b are from 0, nothing alike, to 1,
+// the same ignoring case, punctuation and spacing.  It is one less the
+// edit distance between them over the length of the longer.
+func Similarity(a, b string) float64 {
+	ra, rb := similarityRunes(a), similarityRunes(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// similarityRunes returns the lower cased letters and digits of s with
+// single spaces between words.
+func similarityRunes(s string) []rune {
+	var runes []rune
+	space := false
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			if space && len(runes) > 0 {
+				runes = append(runes, ' ')
+			}
+			runes = append(runes, unicode.ToLower(r))
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return runes
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// WriteText writes the report as text, a paragraph per line.
+func (cr *CompareReport) WriteText(w io.Writer) error {
+	width := 0
+	for _, engine := range cr.Engines {
+		if len(engine) > width {
+			width = len(engine)
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s to %s: %d of %d lines disagree (similarity below %.2f)\n",
+		cr.MainLang, cr.Lang, cr.Disagree, cr.Compared, cr.Similarity)
+	for _, line := range cr.Lines {
+		fmt.Fprintf(&b, "\nline %d similarity %.2f\n", line.Line, line.Similarity)
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, cr.MainLang, line.Source)
+		for i, translation := range line.Translations {
+			fmt.Fprintf(&b, "  %-*s  %s\n", width, cr.Engines[i], translation)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func (cr *CompareReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cr)
+}
+
+var compareHTML = template.Must(template.New("compare").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.MainLang}} to {{.Lang}} comparison</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+tr.disagree td.similarity { color: #b00; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.MainLang}} to {{.Lang}}</h1>
+<p>{{.Disagree}} of {{.Compared}} lines disagree (similarity below {{printf "%.2f" .Similarity}}).</p>
+<table>
+<tr><th>Line</th><th>Similarity</th><th>{{.MainLang}}</th>{{range .Engines}}<th>{{.}}</th>{{end}}</tr>
+{{range .Lines}}<tr{{if .Disagree}} class="disagree"{{end}}><td>{{.Line}}</td><td class="similarity">{{printf "%.2f" .Similarity}}</td><td>{{.Source}}</td>{{range .Translations}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as an HTML page with a table of the lines.
+func (cr *CompareReport) WriteHTML(w io.Writer) error {
+	return compareHTML.Execute(w, cr)
+}
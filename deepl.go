@@ -0,0 +1,307 @@
+// deepl.go
+// DeepL API Translator.
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DEEPL_ENDPOINT      = "https://api.deepl.com"
+	DEEPL_FREE_ENDPOINT = "https://api-free.deepl.com"
+	// DEEPL_FREE_SUFFIX ends the authentication keys of free accounts.
+	DEEPL_FREE_SUFFIX = ":fx"
+	// DeepL allows at most 50 texts and a 128 KiB request body, of which
+	// 1 KiB is left for the parameters other than the texts.
+	DEEPL_MAX_SEGMENTS = 50
+	DEEPL_MAX_BYTES    = 127 * 1024
+)
+
+// deeplTargets maps language codes to the DeepL target codes that differ
+// from the upper cased language.  DeepL needs a variant of English and
+// Portuguese.
+var deeplTargets = map[string]string{
+	"en":      "EN-US",
+	"pt":      "PT-PT",
+	"no":      "NB",
+	"zh":      "ZH-HANS",
+	"zh-cn":   "ZH-HANS",
+	"zh-hans": "ZH-HANS",
+	"zh-tw":   "ZH-HANT",
+	"zh-hk":   "ZH-HANT",
+	"zh-hant": "ZH-HANT",
+}
+
+// deeplFormalities are the DeepL target codes that have formality.
+var deeplFormalities = map[string]bool{
+	"DE":    true,
+	"ES":    true,
+	"FR":    true,
+	"IT":    true,
+	"JA":    true,
+	"NL":    true,
+	"PL":    true,
+	"PT-BR": true,
+	"PT-PT": true,
+	"RU":    true,
+}
+
+// DeepLOptions are the optional settings for a DeepLTranslator.
+type DeepLOptions struct {
+	// Endpoint replaces the API URL, by default DEEPL_ENDPOINT or
+	// DEEPL_FREE_ENDPOINT for a free account key.
+	Endpoint string
+	// Formality, by target language, is more, less, prefer_more,
+	// prefer_less or default.  The "*" entry is for all other languages.
+	// more and less fail for languages without formality, the prefer_
+	// forms do not.
+	Formality map[string]string
+	// Languages maps language codes to DeepL codes, overriding the
+	// built-in mapping such as pt to PT-PT.
+	Languages map[string]string
+	// MimeType of the texts, MIME_TEXT if "" or MIME_HTML.
+	MimeType string
+	// Timeout limits each call to the API.  0 means no limit.
+	Timeout time.Duration
+}
+
+// DeepLTranslator is a Translator using the DeepL API.
+type DeepLTranslator struct {
+	authKey  string
+	endpoint string
+	options  DeepLOptions
+	client   *http.Client
+}
+
+// NewDeepLTranslator returns a DeepLTranslator using authKey.  nil options
+// uses the defaults.
+func NewDeepLTranslator(authKey string, options *DeepLOptions) (*DeepLTranslator, error) {
+	dt := &DeepLTranslator{authKey: authKey}
+	if options != nil {
+		dt.options = *options
+	}
+	var err error
+	dt.options.MimeType, err = googleMimeType(dt.options.MimeType)
+	if err != nil {
+		return nil, err
+	}
+	dt.endpoint = dt.options.Endpoint
+	if dt.endpoint == "" {
+		dt.endpoint = DEEPL_ENDPOINT
+		if strings.HasSuffix(authKey, DEEPL_FREE_SUFFIX) {
+			dt.endpoint = DEEPL_FREE_ENDPOINT
+		}
+	}
+	dt.endpoint = strings.TrimSuffix(dt.endpoint, "/")
+	dt.client = &http.Client{Timeout: dt.options.Timeout}
+	return dt, nil
+}
+
+// DeepLSource returns the DeepL code for translating from lang, which is
+// just the language without any region.
+func DeepLSource(lang string) string {
+	return strings.ToUpper(strings.SplitN(lang, "-", 2)[0])
+}
+
+// DeepLTarget returns the DeepL code for translating into lang.
+func DeepLTarget(lang string) string {
+	lower := strings.ToLower(lang)
+	if code, ok := deeplTargets[lower]; ok {
+		return code
+	}
+	switch lower {
+	case "en-gb", "en-us", "pt-br", "pt-pt":
+		return strings.ToUpper(lang)
+	}
+	return DeepLSource(lang)
+}
+
+func (dt *DeepLTranslator) target(lang string) string {
+	if code, ok := dt.options.Languages[lang]; ok {
+		return code
+	}
+	return DeepLTarget(lang)
+}
+
+func (dt *DeepLTranslator) source(lang string) string {
+	if code, ok := dt.options.Languages[lang]; ok {
+		return DeepLSource(code)
+	}
+	return DeepLSource(lang)
+}
+
+// formality returns the formality for target, "" for the default.
+func (dt *DeepLTranslator) formality(target string) string {
+	if formality, ok := dt.options.Formality[target]; ok {
+		return formality
+	}
+	return dt.options.Formality["*"]
+}
+
+// deeplTranslations is the answer to a translate request.
+type deeplTranslations struct {
+	Translations []struct {
+		DetectedSourceLanguage string `json:"detected_source_language"`
+		Text                   string `json:"text"`
+	} `json:"translations"`
+}
+
+// Translate texts from source to target.
+func (dt *DeepLTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	resp, err := dt.translate(ctx, dt.source(source), target, texts)
+	if err != nil {
+		return nil, err
+	}
+	translated := make([]string, len(resp.Translations))
+	for i, translation := range resp.Translations {
+		translated[i] = translation.Text
+	}
+	return translated, nil
+}
+
+// translate sends a translate request, with source "" to detect it.
+func (dt *DeepLTranslator) translate(ctx context.Context, source, target string, texts []string) (*deeplTranslations, error) {
+	form := url.Values{"text": texts}
+	if source != "" {
+		form.Set("source_lang", source)
+	}
+	form.Set("target_lang", dt.target(target))
+	if formality := dt.formality(target); formality != "" {
+		form.Set("formality", formality)
+	}
+	if dt.options.MimeType == MIME_HTML {
+		form.Set("tag_handling", "html")
+	}
+	var resp deeplTranslations
+	err := dt.call(ctx, http.MethodPost, "/v2/translate", form, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("translate got %w", err)
+	}
+	return &resp, nil
+}
+
+// Supported returns the DeepL target languages.  DeepL names languages in
+// English only so displayLang is not used.
+func (dt *DeepLTranslator) Supported(ctx context.Context, displayLang string) ([]Language, error) {
+	var resp []struct {
+		Language string `json:"language"`
+		Name     string `json:"name"`
+	}
+	err := dt.call(ctx, http.MethodGet, "/v2/languages", url.Values{"type": {"target"}}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("supported languages got %w", err)
+	}
+	langs := make([]Language, len(resp))
+	for i, lang := range resp {
+		langs[i] = Language{Code: lang.Language, Name: lang.Name}
+	}
+	return langs, nil
+}
+
+// Detect the language of each text.  DeepL has no detection so the texts
+// are translated, and charged for, to find their language.
+func (dt *DeepLTranslator) Detect(ctx context.Context, texts []string) ([]Detection, error) {
+	resp, err := dt.translate(ctx, "", "en", texts)
+	if err != nil {
+		return nil, err
+	}
+	detections := make([]Detection, len(texts))
+	for i := 0; i < len(resp.Translations) && i < len(texts); i++ {
+		detections[i].Code = strings.ToLower(resp.Translations[i].DetectedSourceLanguage)
+	}
+	return detections, nil
+}
+
+// call makes an API request with the form, decoding the answer into resp.
+func (dt *DeepLTranslator) call(ctx context.Context, method, path string, form url.Values, resp interface{}) error {
+	var body io.Reader
+	target := dt.endpoint + path
+	if method == http.MethodGet {
+		target += "?" + form.Encode()
+	} else {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+dt.authKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	httpResp, err := dt.client.Do(req)
+	if err != nil {
+		return &RetryableError{Err: err}
+	}
+	defer httpResp.Body.Close()
+	data, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return &RetryableError{Err: err}
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return deeplError(httpResp.StatusCode, data)
+	}
+	err = json.Unmarshal(data, resp)
+	if err != nil {
+		return fmt.Errorf("unpacking got %v", err)
+	}
+	return nil
+}
+
+// deeplError returns the error for an API status, marking too many
+// requests and availability problems as retryable.  456 is running out of
+// quota, which waiting will not fix.
+func deeplError(statusCode int, data []byte) error {
+	var message struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(data, &message)
+	err := fmt.Errorf("%d %s %s", statusCode, http.StatusText(statusCode), message.Message)
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusServiceUnavailable, 529:
+		return &RetryableError{Err: err}
+	}
+	return err
+}
+
+// BatchLimits returns the DeepL request limits.
+func (dt *DeepLTranslator) BatchLimits() BatchLimits {
+	return BatchLimits{
+		Segments: DEEPL_MAX_SEGMENTS,
+		Bytes:    DEEPL_MAX_BYTES,
+	}
+}
+
+// EngineName returns the engine name and, as the model, the mime type
+// which changes the translations.
+func (dt *DeepLTranslator) EngineName() (string, string) {
+	if dt.options.MimeType != MIME_TEXT {
+		return "deepl", dt.options.MimeType
+	}
+	return "deepl", ""
+}
+
+// TargetEngineName returns the names for target, with its formality as
+// part of the model if target has formality.
+func (dt *DeepLTranslator) TargetEngineName(target string) (string, string) {
+	engine, model := dt.EngineName()
+	formality := dt.formality(target)
+	if formality == "" || !deeplFormalities[dt.target(target)] {
+		return engine, model
+	}
+	return engine, strings.TrimSpace("formality=" + formality + " " + model)
+}
+
+// Close does nothing.
+func (dt *DeepLTranslator) Close() error {
+	return nil
+}
@@ -0,0 +1,140 @@
+// detect.go
+// Auditing words files by detecting the language of each line, to find
+// lines left untranslated or translated into the wrong language.
+package translate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// detectAliases maps language codes detectors use to those used in words
+// files.
+var detectAliases = map[string]string{
+	"in":  "id",
+	"iw":  "he",
+	"ji":  "yi",
+	"jw":  "jv",
+	"tl":  "fil",
+	"nb":  "no",
+	"zxx": "und",
+}
+
+// DetectOptions are the optional settings for XlnsDetect.
+type DetectOptions struct {
+	// Langs are the languages to audit, all but pseudo-locales if nil.
+	Langs []string
+	// Confidence is the least confidence, from 0 to 1, of a detection to
+	// report.  Detecting the language of short lines is often unsure.
+	Confidence float32
+	// XlnsOptions limit the calls to the Translator as for
+	// XlnsAddTranslator.  Only RequestsPerSecond, CharactersPerMinute,
+	// Retries, Backoff and Budget apply.
+	XlnsOptions *XlnsOptions
+}
+
+// DetectLine is a line detected to be in a language other than that of
+// its words file.
+type DetectLine struct {
+	Lang       string  `json:"lang"`
+	Line       int     `json:"line"` // From 1.
+	Text       string  `json:"text"`
+	Detected   string  `json:"detected"`
+	Confidence float32 `json:"confidence"`
+}
+
+func (dl DetectLine) String() string {
+	return fmt.Sprintf("%s:%d: detected %s (confidence %.2f) in %q",
+		dl.Lang, dl.Line, dl.Detected, dl.Confidence, dl.Text)
+}
+
+// XlnsDetect detects the language of every line of the words files in
+// wordsDir with translator and returns the lines detected to be in a
+// language other than their file's, by language and line.  Only the
+// languages, not the regions, are compared.  Lines without letters and
+// lines whose language is undetermined are not reported.
+func XlnsDetect(ctx context.Context, wordsDir string, translator Translator, options *DetectOptions) ([]DetectLine, error) {
+	if options == nil {
+		options = &DetectOptions{}
+	}
+	langs := options.Langs
+	if langs == nil {
+		all, err := WordsLanguages(wordsDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, lang := range all {
+			if !IsPseudoLocale(lang) {
+				langs = append(langs, lang)
+			}
+		}
+	}
+	// One runner so the budget and rate limits cover every language.
+	xr := newXlnsRunner(wordsDir, translator, "", nil, options.XlnsOptions)
+	var found []DetectLine
+	for _, lang := range langs {
+		words, err := WordsGetWords(wordsDir, lang)
+		if err != nil {
+			return nil, err
+		}
+		var lines []int
+		var texts []string
+		for i, word := range words {
+			if strings.IndexFunc(word, unicode.IsLetter) >= 0 {
+				lines = append(lines, i)
+				texts = append(texts, word)
+			}
+		}
+		start := 0
+		for _, end := range TranslatorDetectBatchLimits(translator).Batches(texts) {
+			batch := texts[start:end]
+			var detections []Detection
+			err = xr.call(ctx, batch, func() error {
+				var err error
+				detections, err = translator.Detect(ctx, batch)
+				return err
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%s got %w", lang, err)
+			}
+			if len(detections) != len(batch) {
+				return nil, fmt.Errorf("%s got %d detections for %d texts",
+					lang, len(detections), len(batch))
+			}
+			for i, detection := range detections {
+				if DetectMatches(lang, detection.Code) ||
+					detection.Confidence < options.Confidence {
+					continue
+				}
+				found = append(found, DetectLine{
+					Lang:       lang,
+					Line:       lines[start+i] + 1,
+					Text:       batch[i],
+					Detected:   detection.Code,
+					Confidence: detection.Confidence,
+				})
+			}
+			start = end
+		}
+	}
+	return found, nil
+}
+
+// DetectMatches returns whether detected, a detected language code, is
+// the language of lang or is undetermined.
+func DetectMatches(lang, detected string) bool {
+	base := detectBase(detected)
+	return base == "" || base == "und" || base == detectBase(lang)
+}
+
+// detectBase returns the lower cased language of code, as used in words
+// files.
+func detectBase(code string) string {
+	base := strings.ToLower(Iso639FromBcp47(code))
+	if alias, ok := detectAliases[base]; ok {
+		return alias
+	}
+	return base
+}
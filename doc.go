@@ -1,4 +1,4 @@
-// translate
-// Commands for translating words and phrases using Google APIs and caching
-// the results to minimize future API calls.
-package translate
+// translate
+// Commands for translating words and phrases using Google APIs and caching
+// the results to minimize future API calls.
+package translate
@@ -0,0 +1,101 @@
+// estimate.go
+// Estimating what XlnsAddTranslator and XlnsUpdateTranslator would send to
+// a Translator.
+package translate
+
+import (
+	"unicode/utf8"
+)
+
+// DEFAULT_PRICE is the Google Translation API price in US dollars per
+// million characters.
+const DEFAULT_PRICE = 20.0
+
+// XlnsEstimate is what a run would send to the Translator.
+type XlnsEstimate struct {
+	Langs      []LangEstimate
+	Segments   int // Texts sent, in total.
+	Characters int // Characters sent, in total.
+	Requests   int // Calls to the Translator, in total.
+}
+
+// LangEstimate is what a run would send to the Translator for one
+// language.
+type LangEstimate struct {
+	Lang       string
+	Segments   int // Texts sent.
+	Characters int // Characters sent.
+	Requests   int // Calls to the Translator.
+	Cached     int // Lines translated from the cache.
+	Kept       int // Lines already up to date or done by a resumed run.
+}
+
+// Cost returns the estimated cost at pricePerMillion characters.
+func (xe *XlnsEstimate) Cost(pricePerMillion float64) float64 {
+	return float64(xe.Characters) * pricePerMillion / 1e6
+}
+
+// XlnsEstimateAdd estimates what XlnsAddTranslator would send to
+// translator without sending anything or writing any files.
+func XlnsEstimateAdd(wordsDir string, translator Translator, mainLang string, newLangs []string, options *XlnsOptions) (*XlnsEstimate, error) {
+	words, jobs, err := xlnsAddJobs(wordsDir, mainLang, newLangs)
+	if err != nil {
+		return nil, err
+	}
+	xr := newXlnsRunner(wordsDir, translator, mainLang, words, options)
+	return xr.estimate("add", jobs)
+}
+
+// XlnsEstimateUpdate estimates what XlnsUpdateTranslator would send to
+// translator without sending anything or writing any files.
+func XlnsEstimateUpdate(wordsDir string, translator Translator, mainLang string, options *XlnsOptions) (*XlnsEstimate, error) {
+	full := options != nil && options.Full
+	words, jobs, err := xlnsUpdateJobs(wordsDir, mainLang, full)
+	if err != nil {
+		return nil, err
+	}
+	xr := newXlnsRunner(wordsDir, translator, mainLang, words, options)
+	return xr.estimate("update", jobs)
+}
+
+// estimate works out what running jobs for command would send.
+func (xr *xlnsRunner) estimate(command string, jobs []*xlnsJob) (*XlnsEstimate, error) {
+	var journal *xlnsJournal
+	if xr.options.Resume {
+		var err error
+		journal, err = readXlnsJournal(xr.wordsDir)
+		if err != nil {
+			return nil, err
+		}
+		if journal != nil && (journal.start.Command != command ||
+			journal.start.Main != xr.mainLang ||
+			journal.start.Words != journalWords(xr.words)) {
+			journal = nil // XlnsAdd or XlnsUpdate would refuse to resume.
+		}
+	}
+	xe := &XlnsEstimate{}
+	for _, job := range jobs {
+		le := LangEstimate{Lang: job.lang, Kept: len(xr.words) - len(job.todo)}
+		if journal != nil {
+			todo := len(job.todo)
+			if !journal.resume(job) {
+				job.todo = nil
+			}
+			le.Kept += todo - len(job.todo)
+		}
+		// Counting the texts as sent, with placeholders and glossary
+		// terms masked.
+		lines, _, masked, _ := xr.pending(job)
+		le.Cached = len(job.todo) - len(lines)
+		le.Segments = len(masked)
+		for _, text := range masked {
+			le.Characters += utf8.RuneCountInString(text)
+		}
+		le.Requests = len(xr.limits.Batches(masked))
+		xe.Langs = append(xe.Langs, le)
+		xe.Segments += le.Segments
+		xe.Characters += le.Characters
+		xe.Requests += le.Requests
+	}
+	return xe, nil
+}
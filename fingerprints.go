@@ -0,0 +1,59 @@
+// fingerprints.go
+// Fingerprints record which mainLang lines a words file was translated from
+// so updates only need to translate new or changed lines.
+package translate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+)
+
+const FINGERPRINTS_SUFFIX = ".fingerprints"
+
+// WordsFingerprint returns the fingerprint of a mainLang line.
+func WordsFingerprint(mainLang, line string) string {
+	sum := sha256.Sum256([]byte(mainLang + "\x00" + line))
+	return hex.EncodeToString(sum[:8])
+}
+
+// WordsFingerprints returns the fingerprints of mainLang lines.
+func WordsFingerprints(mainLang string, lines []string) []string {
+	fingerprints := make([]string, len(lines))
+	for i, line := range lines {
+		fingerprints[i] = WordsFingerprint(mainLang, line)
+	}
+	return fingerprints
+}
+
+// FingerprintsFilename returns the path of the fingerprints file for the
+// given language.
+func FingerprintsFilename(wordsDir, lang string) string {
+	return path.Join(wordsDir, lang+FINGERPRINTS_SUFFIX)
+}
+
+// WordsGetFingerprints returns the fingerprints recorded for lang.  If
+// there are none it returns nil.
+func WordsGetFingerprints(wordsDir, lang string) ([]string, error) {
+	filename := FingerprintsFilename(wordsDir, lang)
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return readLines(filename)
+}
+
+// WordsWriteFingerprints writes the fingerprints for lang.
+func WordsWriteFingerprints(wordsDir, lang string, fingerprints []string) error {
+	return writeLines(FingerprintsFilename(wordsDir, lang), fingerprints)
+}
+
+// WordsRemoveFingerprints removes the fingerprints for lang so the next
+// update retranslates all of it.
+func WordsRemoveFingerprints(wordsDir, lang string) error {
+	err := os.Remove(FingerprintsFilename(wordsDir, lang))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// glossary.go
+// Glossaries of terms with fixed translations, or none at all.
+package translate
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Glossary is a set of terms and how each is translated.  It is read from
+// a CSV file in the Google equivalent term sets format: the first row has
+// the language codes of the columns and each other row has one term in
+// each language.  An empty cell means the row has no term for that
+// language.  A row with a single term, such as a brand name, means that
+// term is never translated.  Lines starting with # are comments.
+type Glossary struct {
+	Langs []string   // The language of each column.
+	Rows  [][]string // The terms, one column per language.
+}
+
+// GlossaryPair is a source language term and its translation.
+type GlossaryPair struct {
+	Source string
+	Target string
+}
+
+// ReadGlossary reads a Glossary from the CSV file filename.
+func ReadGlossary(filename string) (*Glossary, error) {
+	r, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening glossary got %v", err)
+	}
+	defer r.Close()
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading glossary got %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s has no languages", filename)
+	}
+	glossary := &Glossary{Langs: records[0]}
+	for i, record := range records[1:] {
+		if len(record) > len(glossary.Langs) {
+			return nil, fmt.Errorf("%s row %d has %d terms for %d languages",
+				filename, i+2, len(record), len(glossary.Langs))
+		}
+		row := make([]string, len(glossary.Langs))
+		for j, term := range record {
+			row[j] = strings.TrimSpace(term)
+		}
+		glossary.Rows = append(glossary.Rows, row)
+	}
+	return glossary, nil
+}
+
+// column returns the column for lang, or its base language, or -1 if
+// there is none.
+func (g *Glossary) column(lang string) int {
+	base := strings.SplitN(lang, "-", 2)[0]
+	found := -1
+	for i, glang := range g.Langs {
+		if strings.EqualFold(glang, lang) {
+			return i
+		}
+		if found < 0 && strings.EqualFold(glang, base) {
+			found = i
+		}
+	}
+	return found
+}
+
+// Pairs returns the terms in source and how they translate into target,
+// longest source term first.  Terms that are never translated are paired
+// with themselves.
+func (g *Glossary) Pairs(source, target string) []GlossaryPair {
+	from := g.column(source)
+	to := g.column(target)
+	var pairs []GlossaryPair
+	for _, row := range g.Rows {
+		terms := 0
+		only := ""
+		for _, term := range row {
+			if term != "" {
+				terms++
+				only = term
+			}
+		}
+		switch {
+		case terms == 1:
+			pairs = append(pairs, GlossaryPair{only, only})
+		case from >= 0 && to >= 0 && row[from] != "" && row[to] != "":
+			pairs = append(pairs, GlossaryPair{row[from], row[to]})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return len(pairs[i].Source) > len(pairs[j].Source)
+	})
+	return pairs
+}
+
+// glossaryFind returns the byte offsets of the whole word occurrences of
+// term in text.
+func glossaryFind(text, term string) []int {
+	var found []int
+	for start := 0; term != "" && start < len(text); {
+		i := strings.Index(text[start:], term)
+		if i < 0 {
+			break
+		}
+		i += start
+		end := i + len(term)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !glossaryWordRune(before) && !glossaryWordRune(after) {
+			found = append(found, i)
+			start = end
+		} else {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			start = i + size
+		}
+	}
+	return found
+}
+
+func glossaryWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// maskGlossary replaces the source terms of pairs found in text with
+// tokens, like MaskPlaceholders, continuing the numbering of restore.  It
+// returns the masked text and restore with the target terms added, ready
+// for UnmaskPlaceholders.
+func maskGlossary(text string, pairs []GlossaryPair, restore []string) (string, []string) {
+	for _, pair := range pairs {
+		found := glossaryFind(text, pair.Source)
+		for i := len(found) - 1; i >= 0; i-- {
+			token := fmt.Sprintf("⟦%d⟧", len(restore))
+			restore = append(restore, pair.Target)
+			text = text[:found[i]] + token + text[found[i]+len(pair.Source):]
+		}
+	}
+	return text, restore
+}
+
+// GlossaryViolations returns the pairs whose source term is in source but
+// whose target term is missing from translation.
+func GlossaryViolations(pairs []GlossaryPair, source, translation string) []GlossaryPair {
+	var violations []GlossaryPair
+	for _, pair := range pairs {
+		if len(glossaryFind(source, pair.Source)) != 0 &&
+			!strings.Contains(translation, pair.Target) {
+			violations = append(violations, pair)
+		}
+	}
+	return violations
+}
+
+// WordsCheckGlossary returns the lines in wordsDir whose translation from
+// mainLang does not use the terms in glossary.
+func WordsCheckGlossary(wordsDir, mainLang string, glossary *Glossary) ([]WordsProblem, error) {
+	pairs := make(map[string][]GlossaryPair)
+	return wordsCheckLines(wordsDir, mainLang, func(lang, source, translation string) string {
+		langPairs, ok := pairs[lang]
+		if !ok {
+			langPairs = glossary.Pairs(mainLang, lang)
+			pairs[lang] = langPairs
+		}
+		violations := GlossaryViolations(langPairs, source, translation)
+		if len(violations) == 0 {
+			return ""
+		}
+		messages := make([]string, len(violations))
+		for i, violation := range violations {
+			messages[i] = fmt.Sprintf("glossary %q expected %q",
+				violation.Source, violation.Target)
+		}
+		return strings.Join(messages, ", ")
+	})
+}
@@ -0,0 +1,373 @@
+// google.go
+// Google Cloud Translation API V3 Translator.
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	tr "cloud.google.com/go/translate/apiv3"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	trpb "google.golang.org/genproto/googleapis/cloud/translate/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	PROJECT_ID       = "project_id"
+	PROJECT_ENV      = "GOOGLE_CLOUD_PROJECT"
+	DEFAULT_LOCATION = "global"
+	MIME_TEXT        = "text/plain"
+	MIME_HTML        = "text/html"
+	// Google recommends less than 30k code points per request, and allows
+	// at most 1024 texts.
+	GOOGLE_MAX_SEGMENTS    = 1024
+	GOOGLE_MAX_CODE_POINTS = 30000
+)
+
+// GoogleTranslator is a Translator using the Google Cloud Translation API
+// V3.
+type GoogleTranslator struct {
+	client   *tr.TranslationClient
+	parent   string
+	options  GoogleOptions
+	model    string // Model resource name, "" for the default.
+	glossary string // Glossary resource name, "" for none.
+}
+
+// GoogleOptions are the optional settings for a GoogleTranslator.
+type GoogleOptions struct {
+	// ProjectID is the Google Cloud project used.  If "" it is taken from
+	// the credentials, or the PROJECT_ENV environment variable.
+	ProjectID string
+	// Location is the region used, DEFAULT_LOCATION if "".  Custom models
+	// and glossaries need a regional location such as us-central1.
+	Location string
+	// Model is the ID of a custom AutoML or adaptive model in the project
+	// and location, a Google model such as general/nmt or a full model
+	// resource name.  "" uses the default model.
+	Model string
+	// MimeType of the texts, MIME_TEXT if "" or MIME_HTML.
+	MimeType string
+	// Endpoint replaces the API endpoint, a host:port for the V3 API or
+	// the URL the V2 API paths are added to, such as a local fake server.
+	Endpoint string
+	// Insecure connects to Endpoint without TLS, or for the V2 API
+	// without checking its certificate, and without authentication.
+	Insecure bool
+	// Timeout limits each call to the API.  0 means no limit.
+	Timeout time.Duration
+	// Proxy is the URL of an HTTP proxy to connect through.  The
+	// HTTPS_PROXY environment variable is used if "".
+	Proxy string
+}
+
+// NewGoogleTranslator returns a GoogleTranslator using the service account
+// in credentialsJson.  Close it when done.
+func NewGoogleTranslator(ctx context.Context, credentialsJson string) (*GoogleTranslator, error) {
+	return NewGoogleTranslatorOptions(ctx, credentialsJson, nil)
+}
+
+// NewGoogleTranslatorOptions is NewGoogleTranslator with options.  nil
+// options uses the defaults.  If credentialsJson is "" the Application
+// Default Credentials are used: GOOGLE_APPLICATION_CREDENTIALS, the gcloud
+// default credentials or those of the environment, such as workload
+// identity.
+func NewGoogleTranslatorOptions(ctx context.Context, credentialsJson string, options *GoogleOptions) (*GoogleTranslator, error) {
+	gt, err := newGoogleTranslator(options)
+	if err != nil {
+		return nil, err
+	}
+	projectId := gt.options.ProjectID
+	if projectId == "" && gt.options.Insecure && credentialsJson == "" {
+		projectId = os.Getenv(PROJECT_ENV)
+		if projectId == "" {
+			return nil, fmt.Errorf("insecure needs a project ID")
+		}
+	}
+	if projectId == "" {
+		projectId, err = findProjectId(ctx, credentialsJson)
+		if err != nil {
+			return nil, err
+		}
+	}
+	gt.parent = fmt.Sprintf("projects/%s/locations/%s", projectId, gt.options.Location)
+	gt.model = gt.resource("models", gt.options.Model)
+	clientOptions, err := googleGRPCOptions(ctx, gt.options)
+	if err != nil {
+		return nil, err
+	}
+	if credentialsJson != "" && !gt.options.Insecure {
+		clientOptions = append(clientOptions, option.WithCredentialsFile(credentialsJson))
+	}
+	gt.client, err = tr.NewTranslationClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("new client got %v", err)
+	}
+	return gt, nil
+}
+
+// NewGoogleNamer returns a GoogleTranslator with the BatchLimits and
+// EngineName of the one NewGoogleTranslatorOptions would return, for
+// estimates.  It has no client so needs no credentials, and its other
+// methods fail.  The project, needed to name a glossary given by its ID,
+// is found the same way if it can be.
+func NewGoogleNamer(ctx context.Context, credentialsJson string, options *GoogleOptions) (*GoogleTranslator, error) {
+	gt, err := newGoogleTranslator(options)
+	if err != nil {
+		return nil, err
+	}
+	projectId := gt.options.ProjectID
+	if projectId == "" {
+		projectId, _ = findProjectId(ctx, credentialsJson)
+	}
+	if projectId == "" {
+		projectId = os.Getenv(PROJECT_ENV)
+	}
+	gt.parent = fmt.Sprintf("projects/%s/locations/%s", projectId, gt.options.Location)
+	gt.model = gt.resource("models", gt.options.Model)
+	return gt, nil
+}
+
+// newGoogleTranslator returns a GoogleTranslator, without a client, with
+// the defaults for options filled in.
+func newGoogleTranslator(options *GoogleOptions) (*GoogleTranslator, error) {
+	gt := &GoogleTranslator{}
+	if options != nil {
+		gt.options = *options
+	}
+	if gt.options.Location == "" {
+		gt.options.Location = DEFAULT_LOCATION
+	}
+	var err error
+	gt.options.MimeType, err = googleMimeType(gt.options.MimeType)
+	if err != nil {
+		return nil, err
+	}
+	return gt, nil
+}
+
+// noClient returns an error if gt was made by NewGoogleNamer.
+func (gt *GoogleTranslator) noClient() error {
+	if gt.client == nil {
+		return fmt.Errorf("google namer has no client")
+	}
+	return nil
+}
+
+// resource returns the full resource name of the name of kind, which may
+// already be one, in the translator's project and location.
+func (gt *GoogleTranslator) resource(kind, name string) string {
+	if name == "" || strings.HasPrefix(name, "projects/") {
+		return name
+	}
+	return gt.parent + "/" + kind + "/" + name
+}
+
+// SetGlossary makes Translate use a glossary created in Google Cloud.
+// glossary is its ID, in the translator's project and location, or its
+// full resource name.  Glossaries are not available in the global
+// location.
+func (gt *GoogleTranslator) SetGlossary(glossary string) {
+	gt.glossary = gt.resource("glossaries", glossary)
+}
+
+// AppliesGlossary returns whether a glossary is set.
+func (gt *GoogleTranslator) AppliesGlossary(source, target string) bool {
+	return gt.glossary != ""
+}
+
+// Translate texts from source to target.
+func (gt *GoogleTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	if err := gt.noClient(); err != nil {
+		return nil, err
+	}
+	req := &trpb.TranslateTextRequest{
+		Parent:             gt.parent,
+		SourceLanguageCode: source,
+		TargetLanguageCode: target,
+		MimeType:           gt.options.MimeType,
+		Contents:           texts,
+		Model:              gt.model,
+	}
+	if gt.glossary != "" {
+		req.GlossaryConfig = &trpb.TranslateTextGlossaryConfig{Glossary: gt.glossary}
+	}
+	ctx, cancel := googleTimeout(ctx, gt.options.Timeout)
+	defer cancel()
+	resp, err := gt.client.TranslateText(ctx, req)
+	if err != nil {
+		return nil, googleError("translate text", err)
+	}
+	translations := resp.GetTranslations()
+	if gt.glossary != "" {
+		translations = resp.GetGlossaryTranslations()
+	}
+	translated := make([]string, len(translations))
+	for i, translation := range translations {
+		translated[i] = translation.GetTranslatedText()
+	}
+	return translated, nil
+}
+
+// Supported returns the Google supported languages named in displayLang.
+func (gt *GoogleTranslator) Supported(ctx context.Context, displayLang string) ([]Language, error) {
+	if err := gt.noClient(); err != nil {
+		return nil, err
+	}
+	req := &trpb.GetSupportedLanguagesRequest{
+		Parent:              gt.parent,
+		DisplayLanguageCode: displayLang}
+	ctx, cancel := googleTimeout(ctx, gt.options.Timeout)
+	defer cancel()
+	resp, err := gt.client.GetSupportedLanguages(ctx, req)
+	if err != nil {
+		return nil, googleError("supported languages", err)
+	}
+	langs := make([]Language, 0, len(resp.Languages))
+	for _, lang := range resp.Languages {
+		langs = append(langs, Language{
+			Code: lang.LanguageCode,
+			Name: lang.DisplayName,
+		})
+	}
+	return langs, nil
+}
+
+// Detect the language of each text.  The API detects one text per call.
+func (gt *GoogleTranslator) Detect(ctx context.Context, texts []string) ([]Detection, error) {
+	if err := gt.noClient(); err != nil {
+		return nil, err
+	}
+	detections := make([]Detection, len(texts))
+	for i, text := range texts {
+		req := &trpb.DetectLanguageRequest{
+			Parent:   gt.parent,
+			MimeType: gt.options.MimeType,
+			Source:   &trpb.DetectLanguageRequest_Content{Content: text},
+		}
+		resp, err := gt.detect(ctx, req)
+		if err != nil {
+			return nil, googleError("detect language", err)
+		}
+		// Languages are ordered most likely first.
+		if langs := resp.GetLanguages(); len(langs) > 0 {
+			detections[i] = Detection{
+				Code:       langs[0].GetLanguageCode(),
+				Confidence: langs[0].GetConfidence(),
+			}
+		}
+	}
+	return detections, nil
+}
+
+func (gt *GoogleTranslator) detect(ctx context.Context, req *trpb.DetectLanguageRequest) (*trpb.DetectLanguageResponse, error) {
+	ctx, cancel := googleTimeout(ctx, gt.options.Timeout)
+	defer cancel()
+	return gt.client.DetectLanguage(ctx, req)
+}
+
+// BatchLimits returns the Google request limits.
+func (gt *GoogleTranslator) BatchLimits() BatchLimits {
+	return BatchLimits{
+		Segments:   GOOGLE_MAX_SEGMENTS,
+		CodePoints: GOOGLE_MAX_CODE_POINTS,
+	}
+}
+
+// DetectBatchLimits returns one text per batch as the API detects one
+// text per call, so each call is rate limited and retried on its own.
+func (gt *GoogleTranslator) DetectBatchLimits() BatchLimits {
+	return BatchLimits{
+		Segments:   1,
+		CodePoints: GOOGLE_MAX_CODE_POINTS,
+	}
+}
+
+// EngineName returns the engine and model names.  The model name has the
+// mime type added for HTML, and the glossary if one is set, which change
+// the translations.
+func (gt *GoogleTranslator) EngineName() (string, string) {
+	model := gt.options.Model
+	if gt.options.MimeType != MIME_TEXT {
+		model = strings.TrimSpace(model + " " + gt.options.MimeType)
+	}
+	if gt.glossary != "" {
+		model = strings.TrimSpace(model + " " + gt.glossary)
+	}
+	return "google", model
+}
+
+// Close the underlying client, if any.
+func (gt *GoogleTranslator) Close() error {
+	if gt.client == nil {
+		return nil
+	}
+	return gt.client.Close()
+}
+
+// googleMimeType checks mimeType, returning MIME_TEXT for "".
+func googleMimeType(mimeType string) (string, error) {
+	switch mimeType {
+	case "":
+		return MIME_TEXT, nil
+	case MIME_TEXT, MIME_HTML:
+		return mimeType, nil
+	}
+	return "", fmt.Errorf("mime type %s not %s or %s", mimeType, MIME_TEXT, MIME_HTML)
+}
+
+// googleError wraps an API error marking quota and availability problems
+// as retryable.
+func googleError(what string, err error) error {
+	switch status.Code(err) {
+	case codes.ResourceExhausted, codes.Unavailable, codes.Aborted:
+		err = &RetryableError{Err: err}
+	}
+	return fmt.Errorf("%s got %w", what, err)
+}
+
+// findProjectId returns the project ID of credentialsJson, or of the
+// Application Default Credentials if it is "", falling back to the
+// PROJECT_ENV environment variable.
+func findProjectId(ctx context.Context, credentialsJson string) (string, error) {
+	if credentialsJson != "" {
+		return projectId(credentialsJson)
+	}
+	credentials, err := google.FindDefaultCredentials(ctx, tr.DefaultAuthScopes()...)
+	if err != nil {
+		return "", fmt.Errorf("default credentials got %v", err)
+	}
+	if credentials.ProjectID != "" {
+		return credentials.ProjectID, nil
+	}
+	if id := os.Getenv(PROJECT_ENV); id != "" {
+		return id, nil
+	}
+	return "", fmt.Errorf("no project in default credentials, set %s or the project ID",
+		PROJECT_ENV)
+}
+
+// projectId returns the project ID in credentialsJson.
+func projectId(credentialsJson string) (string, error) {
+	credentials, err := ioutil.ReadFile(credentialsJson)
+	if err != nil {
+		return "", fmt.Errorf("reading credentials got %v", err)
+	}
+	data := make(map[string]string)
+	err = json.Unmarshal(credentials, &data)
+	if err != nil {
+		return "", fmt.Errorf("unpacking credentials got %v", err)
+	}
+	id, ok := data[PROJECT_ID]
+	if !ok {
+		return "", fmt.Errorf("no %s in credentials", PROJECT_ID)
+	}
+	return id, nil
+}
@@ -0,0 +1,142 @@
+// googletransport.go
+// Endpoints, transports, timeouts and proxies for the Google Translators.
+package translate
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+	"google.golang.org/grpc"
+)
+
+// googleGRPCOptions returns the client options for the endpoint, transport
+// and proxy in options, for the V3 API over gRPC.  An insecure connection
+// is dialed here, without TLS or authentication.
+func googleGRPCOptions(ctx context.Context, options GoogleOptions) ([]option.ClientOption, error) {
+	var clientOptions []option.ClientOption
+	var dialOptions []grpc.DialOption
+	if options.Proxy != "" {
+		dialer, err := proxyDialer(options.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		dialOptions = append(dialOptions, grpc.WithContextDialer(dialer))
+	}
+	if options.Insecure {
+		if options.Endpoint == "" {
+			return nil, fmt.Errorf("insecure needs an endpoint")
+		}
+		dialOptions = append(dialOptions, grpc.WithInsecure())
+		conn, err := grpc.DialContext(ctx, options.Endpoint, dialOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("dialing %s got %v", options.Endpoint, err)
+		}
+		return []option.ClientOption{option.WithGRPCConn(conn)}, nil
+	}
+	if options.Endpoint != "" {
+		clientOptions = append(clientOptions, option.WithEndpoint(options.Endpoint))
+	}
+	for _, dialOption := range dialOptions {
+		clientOptions = append(clientOptions, option.WithGRPCDialOption(dialOption))
+	}
+	return clientOptions, nil
+}
+
+// googleHTTPOptions returns the client options for apiKey and the
+// endpoint, transport, timeout and proxy in options, for the V2 API over
+// HTTP.  Insecure skips authentication and TLS verification.
+func googleHTTPOptions(ctx context.Context, apiKey string, options GoogleOptions) ([]option.ClientOption, error) {
+	var clientOptions []option.ClientOption
+	if options.Endpoint != "" {
+		clientOptions = append(clientOptions, option.WithEndpoint(options.Endpoint))
+	}
+	if options.Proxy == "" && !options.Insecure && options.Timeout == 0 {
+		return append(clientOptions, option.WithAPIKey(apiKey)), nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.Proxy != "" {
+		proxy, err := url.Parse(options.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("bad proxy %s (%v)", options.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	var roundTripper http.RoundTripper = transport
+	if options.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	} else {
+		var err error
+		roundTripper, err = htransport.NewTransport(ctx, transport, option.WithAPIKey(apiKey))
+		if err != nil {
+			return nil, fmt.Errorf("new transport got %v", err)
+		}
+	}
+	client := &http.Client{Transport: roundTripper, Timeout: options.Timeout}
+	return append(clientOptions, option.WithHTTPClient(client)), nil
+}
+
+// googleTimeout returns ctx limited to timeout, if it is not 0.
+func googleTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// proxyDialer returns a gRPC dialer connecting through the HTTP proxy at
+// proxyUrl with CONNECT.
+func proxyDialer(proxyUrl string) (func(context.Context, string) (net.Conn, error), error) {
+	proxy, err := url.Parse(proxyUrl)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("bad proxy %s", proxyUrl)
+	}
+	return func(ctx context.Context, address string) (net.Conn, error) {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", proxy.Host)
+		if err != nil {
+			return nil, fmt.Errorf("dialing proxy got %v", err)
+		}
+		req := &http.Request{
+			Method: http.MethodConnect,
+			URL:    &url.URL{Host: address},
+			Host:   address,
+			Header: make(http.Header),
+		}
+		if user := proxy.User; user != nil {
+			password, _ := user.Password()
+			auth := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+			req.Header.Set("Proxy-Authorization", "Basic "+auth)
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+			defer conn.SetDeadline(time.Time{})
+		}
+		err = req.Write(conn)
+		if err == nil {
+			var resp *http.Response
+			// The server says nothing more until the client does, so
+			// nothing is lost in the buffer.
+			resp, err = http.ReadResponse(bufio.NewReader(conn), req)
+			if err == nil {
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					err = fmt.Errorf("%s", resp.Status)
+				}
+			}
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy connecting to %s got %v", address, err)
+		}
+		return conn, nil
+	}, nil
+}
@@ -0,0 +1,156 @@
+// googlev2.go
+// Google Cloud Translation API V2 (Basic) Translator using an API key.
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	tr2 "cloud.google.com/go/translate"
+	"golang.org/x/text/language"
+	"google.golang.org/api/googleapi"
+)
+
+// The V2 API allows at most 128 texts per request.
+const GOOGLE_V2_MAX_SEGMENTS = 128
+
+// GoogleV2Translator is a Translator using the Google Cloud Translation API
+// V2, which can be called with just an API key.  It has no locations,
+// custom models or glossaries.
+type GoogleV2Translator struct {
+	client  *tr2.Client
+	options GoogleOptions
+}
+
+// NewGoogleV2Translator returns a GoogleV2Translator using apiKey.  The
+// Location and ProjectID of options are not used, and Model can only be
+// nmt or base.  Close it when done.
+func NewGoogleV2Translator(ctx context.Context, apiKey string, options *GoogleOptions) (*GoogleV2Translator, error) {
+	gt := &GoogleV2Translator{}
+	if options != nil {
+		gt.options = *options
+	}
+	var err error
+	gt.options.MimeType, err = googleMimeType(gt.options.MimeType)
+	if err != nil {
+		return nil, err
+	}
+	clientOptions, err := googleHTTPOptions(ctx, apiKey, gt.options)
+	if err != nil {
+		return nil, err
+	}
+	client, err := tr2.NewClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("new client got %v", err)
+	}
+	gt.client = client
+	return gt, nil
+}
+
+// Translate texts from source to target.
+func (gt *GoogleV2Translator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	sourceTag, err := language.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("bad source %s (%v)", source, err)
+	}
+	targetTag, err := language.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("bad target %s (%v)", target, err)
+	}
+	// The API translates HTML unless told otherwise.
+	format := tr2.Text
+	if gt.options.MimeType == MIME_HTML {
+		format = tr2.HTML
+	}
+	translations, err := gt.client.Translate(ctx, texts, targetTag, &tr2.Options{
+		Source: sourceTag,
+		Format: format,
+		Model:  gt.options.Model,
+	})
+	if err != nil {
+		return nil, googleV2Error("translate", err)
+	}
+	translated := make([]string, len(translations))
+	for i, translation := range translations {
+		translated[i] = translation.Text
+	}
+	return translated, nil
+}
+
+// Supported returns the Google supported languages named in displayLang.
+func (gt *GoogleV2Translator) Supported(ctx context.Context, displayLang string) ([]Language, error) {
+	tag, err := language.Parse(displayLang)
+	if err != nil {
+		return nil, fmt.Errorf("bad displayLang %s (%v)", displayLang, err)
+	}
+	supported, err := gt.client.SupportedLanguages(ctx, tag)
+	if err != nil {
+		return nil, googleV2Error("supported languages", err)
+	}
+	langs := make([]Language, len(supported))
+	for i, lang := range supported {
+		langs[i] = Language{Code: lang.Tag.String(), Name: lang.Name}
+	}
+	return langs, nil
+}
+
+// Detect the language of each text.
+func (gt *GoogleV2Translator) Detect(ctx context.Context, texts []string) ([]Detection, error) {
+	detected, err := gt.client.DetectLanguage(ctx, texts)
+	if err != nil {
+		return nil, googleV2Error("detect language", err)
+	}
+	detections := make([]Detection, len(texts))
+	for i := 0; i < len(detected) && i < len(texts); i++ {
+		// Take the most confident.
+		for _, detection := range detected[i] {
+			confidence := float32(detection.Confidence)
+			if detections[i].Code == "" || confidence > detections[i].Confidence {
+				detections[i] = Detection{
+					Code:       detection.Language.String(),
+					Confidence: confidence,
+				}
+			}
+		}
+	}
+	return detections, nil
+}
+
+// BatchLimits returns the Google V2 request limits.
+func (gt *GoogleV2Translator) BatchLimits() BatchLimits {
+	return BatchLimits{
+		Segments:   GOOGLE_V2_MAX_SEGMENTS,
+		CodePoints: GOOGLE_MAX_CODE_POINTS,
+	}
+}
+
+// EngineName returns the engine and model names.  The model name has the
+// mime type added for HTML, like GoogleTranslator.
+func (gt *GoogleV2Translator) EngineName() (string, string) {
+	model := gt.options.Model
+	if gt.options.MimeType != MIME_TEXT {
+		model = strings.TrimSpace(model + " " + gt.options.MimeType)
+	}
+	return "google-v2", model
+}
+
+// Close the underlying client.
+func (gt *GoogleV2Translator) Close() error {
+	return gt.client.Close()
+}
+
+// googleV2Error wraps an API error marking quota and availability problems
+// as retryable.
+func googleV2Error(what string, err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			err = &RetryableError{Err: err}
+		}
+	}
+	return fmt.Errorf("%s got %w", what, err)
+}
@@ -0,0 +1,266 @@
+// journal.go
+// A journal of the progress of XlnsAdd and XlnsUpdate runs so failed runs
+// can be resumed.
+package translate
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const JOURNAL_FILENAME = ".translate.journal"
+
+// JournalStatus is what the journal of an unfinished run records.
+type JournalStatus struct {
+	Command  string         // "add" or "update".
+	MainLang string         // The language translated from.
+	Langs    []string       // The languages being translated to.
+	Done     []string       // The languages finished and written.
+	Lines    map[string]int // Lines translated for unfinished languages.
+}
+
+// journalEntry is one line of the journal.  The first starts the run, the
+// rest record translated batches and finished languages.
+type journalEntry struct {
+	Command      string   `json:"command,omitempty"`
+	Main         string   `json:"main,omitempty"`
+	Words        string   `json:"words,omitempty"`
+	Langs        []string `json:"langs,omitempty"`
+	Lang         string   `json:"lang,omitempty"`
+	Lines        []int    `json:"lines,omitempty"`
+	Translations []string `json:"translations,omitempty"`
+	Done         bool     `json:"done,omitempty"`
+}
+
+// xlnsJournal records the progress of a run in JOURNAL_FILENAME in the
+// words directory.  It is removed when the run succeeds.
+type xlnsJournal struct {
+	filename string
+	mu       sync.Mutex
+	w        *os.File
+	enc      *json.Encoder
+	start    journalEntry
+	done     map[string]bool
+	lines    map[string]map[int]string
+}
+
+// JournalFilename returns the path of the journal in wordsDir.
+func JournalFilename(wordsDir string) string {
+	return path.Join(wordsDir, JOURNAL_FILENAME)
+}
+
+// journalWords returns a fingerprint of all of words.
+func journalWords(words []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(words, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// readXlnsJournal reads the journal in wordsDir.  It returns nil if there
+// is none.
+func readXlnsJournal(wordsDir string) (*xlnsJournal, error) {
+	filename := JournalFilename(wordsDir)
+	r, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening journal got %v", err)
+	}
+	defer r.Close()
+	xj := &xlnsJournal{
+		filename: filename,
+		done:     make(map[string]bool),
+		lines:    make(map[string]map[int]string),
+	}
+	br := bufio.NewReader(r)
+	for first := true; ; first = false {
+		line, err := br.ReadBytes('\n')
+		if len(line) != 0 {
+			var entry journalEntry
+			// An entry only partly written, by a crash say, is dropped.
+			if json.Unmarshal(line, &entry) == nil {
+				if first {
+					xj.start = entry
+				} else {
+					xj.record(entry)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading journal got %v", err)
+		}
+	}
+	if xj.start.Command == "" {
+		return nil, fmt.Errorf("%s has no start", filename)
+	}
+	return xj, nil
+}
+
+// record adds entry to the journal's state.
+func (xj *xlnsJournal) record(entry journalEntry) {
+	if entry.Done {
+		xj.done[entry.Lang] = true
+		delete(xj.lines, entry.Lang)
+		return
+	}
+	if len(entry.Lines) != len(entry.Translations) {
+		return
+	}
+	lines := xj.lines[entry.Lang]
+	if lines == nil {
+		lines = make(map[int]string)
+		xj.lines[entry.Lang] = lines
+	}
+	for i, line := range entry.Lines {
+		lines[line] = entry.Translations[i]
+	}
+}
+
+// startXlnsJournal starts the journal for a run in wordsDir.  If resume is
+// set the progress recorded by an unfinished run of the same command on
+// the same words is kept, otherwise any old journal is replaced.
+func startXlnsJournal(wordsDir, command, mainLang string, words []string, langs []string, resume bool) (*xlnsJournal, error) {
+	start := journalEntry{
+		Command: command,
+		Main:    mainLang,
+		Words:   journalWords(words),
+		Langs:   langs,
+	}
+	var xj *xlnsJournal
+	if resume {
+		var err error
+		xj, err = readXlnsJournal(wordsDir)
+		if err != nil {
+			return nil, err
+		}
+		if xj != nil && (xj.start.Command != command || xj.start.Main != mainLang) {
+			return nil, fmt.Errorf("journal is for %s %s not %s %s",
+				xj.start.Command, xj.start.Main, command, mainLang)
+		}
+		if xj != nil && xj.start.Words != start.Words {
+			return nil, fmt.Errorf("%s words changed since the journal started",
+				mainLang)
+		}
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if xj == nil {
+		xj = &xlnsJournal{
+			filename: JournalFilename(wordsDir),
+			start:    start,
+			done:     make(map[string]bool),
+			lines:    make(map[string]map[int]string),
+		}
+		flags |= os.O_TRUNC
+	}
+	w, err := os.OpenFile(xj.filename, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening journal got %v", err)
+	}
+	xj.w = w
+	xj.enc = json.NewEncoder(w)
+	if flags&os.O_TRUNC != 0 {
+		err = xj.enc.Encode(start)
+		if err != nil {
+			w.Close()
+			return nil, fmt.Errorf("writing journal got %v", err)
+		}
+	} else {
+		// Start the next entry on a new line in case the last was partial.
+		_, err = w.Write([]byte("\n"))
+		if err != nil {
+			w.Close()
+			return nil, fmt.Errorf("writing journal got %v", err)
+		}
+	}
+	return xj, nil
+}
+
+// resume removes from job what the journal says is already done.  It
+// returns false if the whole language is done.
+func (xj *xlnsJournal) resume(job *xlnsJob) bool {
+	if xj.done[job.lang] {
+		return false
+	}
+	lines := xj.lines[job.lang]
+	var todo []int
+	for _, line := range job.todo {
+		if xlns, ok := lines[line]; ok {
+			job.lines[line] = xlns
+		} else {
+			todo = append(todo, line)
+		}
+	}
+	job.todo = todo
+	return true
+}
+
+// batch records the translations of lines into lang.  A nil journal, for
+// runs that write nothing, records nothing.
+func (xj *xlnsJournal) batch(lang string, lines []int, translations []string) error {
+	if xj == nil {
+		return nil
+	}
+	return xj.write(journalEntry{Lang: lang, Lines: lines, Translations: translations})
+}
+
+// finish records that lang has been written.
+func (xj *xlnsJournal) finish(lang string) error {
+	return xj.write(journalEntry{Lang: lang, Done: true})
+}
+
+func (xj *xlnsJournal) write(entry journalEntry) error {
+	xj.mu.Lock()
+	defer xj.mu.Unlock()
+	err := xj.enc.Encode(entry)
+	if err != nil {
+		return fmt.Errorf("writing journal got %v", err)
+	}
+	return nil
+}
+
+// close closes the journal, removing it if the run succeeded.
+func (xj *xlnsJournal) close(succeeded bool) error {
+	err := xj.w.Close()
+	if err != nil {
+		return fmt.Errorf("closing journal got %v", err)
+	}
+	if succeeded {
+		return os.Remove(xj.filename)
+	}
+	return nil
+}
+
+// XlnsJournalStatus returns the status of the unfinished run in wordsDir
+// or nil if there is none.
+func XlnsJournalStatus(wordsDir string) (*JournalStatus, error) {
+	xj, err := readXlnsJournal(wordsDir)
+	if err != nil || xj == nil {
+		return nil, err
+	}
+	status := &JournalStatus{
+		Command:  xj.start.Command,
+		MainLang: xj.start.Main,
+		Langs:    xj.start.Langs,
+		Lines:    make(map[string]int),
+	}
+	for lang := range xj.done {
+		status.Done = append(status.Done, lang)
+	}
+	sort.Strings(status.Done)
+	for lang, lines := range xj.lines {
+		status.Lines[lang] = len(lines)
+	}
+	return status, nil
+}
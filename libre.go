@@ -0,0 +1,212 @@
+// libre.go
+// LibreTranslate, or compatible, API Translator for self-hosted servers.
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	LIBRE_ENDPOINT = "http://localhost:5000"
+	// LibreTranslate servers set their own limits, this keeps requests to
+	// a reasonable size.
+	LIBRE_MAX_SEGMENTS = 50
+)
+
+// LibreOptions are the optional settings for a LibreTranslator.
+type LibreOptions struct {
+	// Endpoint is the base URL of the server, LIBRE_ENDPOINT if "".
+	Endpoint string
+	// APIKey is sent with every request if the server needs one.
+	APIKey string
+	// Languages maps language codes to server codes, by default the
+	// language without any region, such as es for es-419.
+	Languages map[string]string
+	// MimeType of the texts, MIME_TEXT if "" or MIME_HTML.
+	MimeType string
+	// Timeout limits each call to the server.  0 means no limit.
+	Timeout time.Duration
+	// Limits replaces the default limit of LIBRE_MAX_SEGMENTS per request,
+	// for servers with a character limit say.
+	Limits *BatchLimits
+}
+
+// LibreTranslator is a Translator using a LibreTranslate server.
+type LibreTranslator struct {
+	endpoint string
+	options  LibreOptions
+	client   *http.Client
+}
+
+// NewLibreTranslator returns a LibreTranslator.  nil options uses the
+// defaults.
+func NewLibreTranslator(options *LibreOptions) (*LibreTranslator, error) {
+	lt := &LibreTranslator{}
+	if options != nil {
+		lt.options = *options
+	}
+	var err error
+	lt.options.MimeType, err = googleMimeType(lt.options.MimeType)
+	if err != nil {
+		return nil, err
+	}
+	lt.endpoint = lt.options.Endpoint
+	if lt.endpoint == "" {
+		lt.endpoint = LIBRE_ENDPOINT
+	}
+	lt.endpoint = strings.TrimSuffix(lt.endpoint, "/")
+	lt.client = &http.Client{Timeout: lt.options.Timeout}
+	return lt, nil
+}
+
+// code returns the server code for lang.
+func (lt *LibreTranslator) code(lang string) string {
+	if code, ok := lt.options.Languages[lang]; ok {
+		return code
+	}
+	return strings.ToLower(strings.SplitN(lang, "-", 2)[0])
+}
+
+// Translate texts from source to target.
+func (lt *LibreTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	format := "text"
+	if lt.options.MimeType == MIME_HTML {
+		format = "html"
+	}
+	req := map[string]interface{}{
+		"q":      texts,
+		"source": lt.code(source),
+		"target": lt.code(target),
+		"format": format,
+	}
+	var resp struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	err := lt.call(ctx, http.MethodPost, "/translate", req, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("translate got %w", err)
+	}
+	return resp.TranslatedText, nil
+}
+
+// Supported returns the languages of the server.  They are named in
+// English only so displayLang is not used.
+func (lt *LibreTranslator) Supported(ctx context.Context, displayLang string) ([]Language, error) {
+	var resp []Language
+	err := lt.call(ctx, http.MethodGet, "/languages", nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("supported languages got %w", err)
+	}
+	return resp, nil
+}
+
+// Detect the language of each text, one text per call.
+func (lt *LibreTranslator) Detect(ctx context.Context, texts []string) ([]Detection, error) {
+	detections := make([]Detection, len(texts))
+	for i, text := range texts {
+		req := map[string]interface{}{"q": text}
+		// Most likely first, with confidence out of 100.
+		var resp []struct {
+			Confidence float32 `json:"confidence"`
+			Language   string  `json:"language"`
+		}
+		err := lt.call(ctx, http.MethodPost, "/detect", req, &resp)
+		if err != nil {
+			return nil, fmt.Errorf("detect language got %w", err)
+		}
+		if len(resp) > 0 {
+			detections[i] = Detection{
+				Code:       resp[0].Language,
+				Confidence: resp[0].Confidence / 100,
+			}
+		}
+	}
+	return detections, nil
+}
+
+// call makes a request to the server with req as JSON, decoding the
+// answer into resp.
+func (lt *LibreTranslator) call(ctx context.Context, method, path string, req map[string]interface{}, resp interface{}) error {
+	var body io.Reader
+	if req != nil {
+		if lt.options.APIKey != "" {
+			req["api_key"] = lt.options.APIKey
+		}
+		data, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, lt.endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpResp, err := lt.client.Do(httpReq)
+	if err != nil {
+		return &RetryableError{Err: err}
+	}
+	defer httpResp.Body.Close()
+	data, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return &RetryableError{Err: err}
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return libreError(httpResp.StatusCode, data)
+	}
+	err = json.Unmarshal(data, resp)
+	if err != nil {
+		return fmt.Errorf("unpacking got %v", err)
+	}
+	return nil
+}
+
+// libreError returns the error for a server status, marking too many
+// requests and availability problems as retryable.
+func libreError(statusCode int, data []byte) error {
+	var message struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(data, &message)
+	err := fmt.Errorf("%d %s %s", statusCode, http.StatusText(statusCode), message.Error)
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return &RetryableError{Err: err}
+	}
+	return err
+}
+
+// BatchLimits returns the request limits.
+func (lt *LibreTranslator) BatchLimits() BatchLimits {
+	if lt.options.Limits != nil {
+		return *lt.options.Limits
+	}
+	return BatchLimits{Segments: LIBRE_MAX_SEGMENTS}
+}
+
+// EngineName returns the engine name and, as the model, the server and
+// mime type.
+func (lt *LibreTranslator) EngineName() (string, string) {
+	model := lt.endpoint
+	if lt.options.MimeType != MIME_TEXT {
+		model += " " + lt.options.MimeType
+	}
+	return "libretranslate", model
+}
+
+// Close does nothing.
+func (lt *LibreTranslator) Close() error {
+	return nil
+}
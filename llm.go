@@ -0,0 +1,376 @@
+// llm.go
+// Translator using a large language model through an OpenAI compatible
+// chat completions API, such as OpenAI, llama.cpp or Ollama servers.
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	LLM_ENDPOINT = "https://api.openai.com/v1"
+	LLM_MODEL    = "gpt-4o-mini"
+	// Models lose track of long lists, these keep batches small enough to
+	// come back whole.
+	LLM_MAX_SEGMENTS    = 40
+	LLM_MAX_CODE_POINTS = 8000
+)
+
+// llmTranslatePrompt is the system prompt for translating.
+const llmTranslatePrompt = `You translate the user interface text of an application.
+The user gives a JSON object with the source and target languages, a
+glossary and a list of texts, each with an id and maybe a note giving its
+context.  Translate each text from the source language into the target
+language.  Use the note to choose the translation but do not translate the
+note.  Where a glossary source term is in a text use its target term.
+Keep tokens like ⟦1⟧, placeholders like %s, {name} and $(NAME), markup and
+leading and trailing spaces exactly as they are.
+Answer with only a JSON object of the form
+{"translations":[{"id":1,"text":"..."}]} with one translation for every id.`
+
+// llmDetectPrompt is the system prompt for detecting languages.
+const llmDetectPrompt = `You detect the language of texts.
+The user gives a JSON object with a list of texts, each with an id.
+Answer with only a JSON object of the form
+{"detections":[{"id":1,"language":"en","confidence":0.9}]} with the BCP-47
+code of the language of every id and a confidence from 0 to 1.`
+
+// LLMOptions are the optional settings for an LLMTranslator.
+type LLMOptions struct {
+	// Endpoint is the base URL of the API, LLM_ENDPOINT if "".  For a
+	// local server it is like http://localhost:8080/v1.
+	Endpoint string
+	// APIKey is sent as a bearer token if not "".
+	APIKey string
+	// Model to use, LLM_MODEL if "".
+	Model string
+	// Glossary, if not nil, is sent with the terms found in each batch.
+	Glossary *Glossary
+	// MimeType of the texts, MIME_TEXT if "" or MIME_HTML.
+	MimeType string
+	// NoJSONMode leaves out asking for a JSON response, for servers that
+	// do not support it.  The prompt still asks for JSON.
+	NoJSONMode bool
+	// Timeout limits each call to the API.  0 means no limit.
+	Timeout time.Duration
+	// Limits replaces the default limits of LLM_MAX_SEGMENTS and
+	// LLM_MAX_CODE_POINTS per request.
+	Limits *BatchLimits
+}
+
+// LLMTranslator is a Translator, and NotesTranslator, using a chat
+// completions API.
+type LLMTranslator struct {
+	endpoint string
+	options  LLMOptions
+	client   *http.Client
+}
+
+// NewLLMTranslator returns an LLMTranslator.  nil options uses the
+// defaults.
+func NewLLMTranslator(options *LLMOptions) (*LLMTranslator, error) {
+	lt := &LLMTranslator{}
+	if options != nil {
+		lt.options = *options
+	}
+	var err error
+	lt.options.MimeType, err = googleMimeType(lt.options.MimeType)
+	if err != nil {
+		return nil, err
+	}
+	if lt.options.Model == "" {
+		lt.options.Model = LLM_MODEL
+	}
+	lt.endpoint = lt.options.Endpoint
+	if lt.endpoint == "" {
+		lt.endpoint = LLM_ENDPOINT
+	}
+	lt.endpoint = strings.TrimSuffix(lt.endpoint, "/")
+	lt.client = &http.Client{Timeout: lt.options.Timeout}
+	return lt, nil
+}
+
+// llmText is a text, with its id, in a request or response.
+type llmText struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+	Note string `json:"note,omitempty"`
+}
+
+// llmTerm is a glossary term in a request.
+type llmTerm struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// Translate texts from source to target.
+func (lt *LLMTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	return lt.TranslateNotes(ctx, source, target, texts, nil)
+}
+
+// TranslateNotes translates texts from source to target with the notes,
+// which may be nil, on each text.  The answer must have one translation
+// for each text, otherwise a RetryableError is returned as models answer
+// differently each time.
+func (lt *LLMTranslator) TranslateNotes(ctx context.Context, source, target string, texts, notes []string) ([]string, error) {
+	req := struct {
+		Source   string    `json:"source"`
+		Target   string    `json:"target"`
+		Format   string    `json:"format"`
+		Glossary []llmTerm `json:"glossary,omitempty"`
+		Texts    []llmText `json:"texts"`
+	}{
+		Source:   llmLanguage(source),
+		Target:   llmLanguage(target),
+		Format:   lt.options.MimeType,
+		Glossary: lt.terms(source, target, texts),
+	}
+	for i, text := range texts {
+		req.Texts = append(req.Texts, llmText{ID: i + 1, Text: text})
+		if i < len(notes) {
+			req.Texts[i].Note = notes[i]
+		}
+	}
+	var resp struct {
+		Translations []llmText `json:"translations"`
+	}
+	err := lt.chat(ctx, llmTranslatePrompt, req, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("translate got %w", err)
+	}
+	translated := make([]string, len(texts))
+	found := make([]bool, len(texts))
+	for _, translation := range resp.Translations {
+		i := translation.ID - 1
+		if i < 0 || i >= len(texts) || found[i] {
+			return nil, &RetryableError{Err: fmt.Errorf(
+				"translate got unexpected id %d", translation.ID)}
+		}
+		translated[i], found[i] = translation.Text, true
+	}
+	if len(resp.Translations) != len(texts) {
+		return nil, &RetryableError{Err: fmt.Errorf(
+			"translate got %d translations for %d texts",
+			len(resp.Translations), len(texts))}
+	}
+	return translated, nil
+}
+
+// terms returns the glossary terms from source to target found in texts.
+func (lt *LLMTranslator) terms(source, target string, texts []string) []llmTerm {
+	if lt.options.Glossary == nil {
+		return nil
+	}
+	var terms []llmTerm
+	for _, pair := range lt.options.Glossary.Pairs(source, target) {
+		for _, text := range texts {
+			if len(glossaryFind(text, pair.Source)) != 0 {
+				terms = append(terms, llmTerm{pair.Source, pair.Target})
+				break
+			}
+		}
+	}
+	return terms
+}
+
+// AppliesGlossary returns whether the glossary is sent with the texts.
+func (lt *LLMTranslator) AppliesGlossary(source, target string) bool {
+	return lt.options.Glossary != nil &&
+		len(lt.options.Glossary.Pairs(source, target)) != 0
+}
+
+// Supported returns the ISO 639-1 languages.  Models can translate most
+// of them, some better than others.  They are named in English only so
+// displayLang is not used.
+func (lt *LLMTranslator) Supported(ctx context.Context, displayLang string) ([]Language, error) {
+	var langs []Language
+	for _, iso639 := range Iso639_2_all() {
+		if iso639.Code != "" {
+			langs = append(langs, Language{Code: iso639.Code, Name: iso639.English[0]})
+		}
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		return langs[i].Code < langs[j].Code
+	})
+	return langs, nil
+}
+
+// Detect asks the model for the language of each text.
+func (lt *LLMTranslator) Detect(ctx context.Context, texts []string) ([]Detection, error) {
+	var req struct {
+		Texts []llmText `json:"texts"`
+	}
+	for i, text := range texts {
+		req.Texts = append(req.Texts, llmText{ID: i + 1, Text: text})
+	}
+	var resp struct {
+		Detections []struct {
+			ID         int     `json:"id"`
+			Language   string  `json:"language"`
+			Confidence float32 `json:"confidence"`
+		} `json:"detections"`
+	}
+	err := lt.chat(ctx, llmDetectPrompt, req, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("detect language got %w", err)
+	}
+	detections := make([]Detection, len(texts))
+	for _, detection := range resp.Detections {
+		if i := detection.ID - 1; i >= 0 && i < len(texts) {
+			detections[i] = Detection{
+				Code:       detection.Language,
+				Confidence: detection.Confidence,
+			}
+		}
+	}
+	return detections, nil
+}
+
+// llmLanguage returns lang with its English name, if known, which models
+// follow better than a code on its own.
+func llmLanguage(lang string) string {
+	name := LanguageForIso639(strings.SplitN(lang, "-", 2)[0])
+	if name == "" {
+		return lang
+	}
+	return lang + " (" + name + ")"
+}
+
+// chat sends the system prompt and req, as JSON, to the model and decodes
+// its JSON answer into resp.
+func (lt *LLMTranslator) chat(ctx context.Context, prompt string, req, resp interface{}) error {
+	content, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	body := map[string]interface{}{
+		"model":       lt.options.Model,
+		"temperature": 0,
+		"messages": []message{
+			{"system", prompt},
+			{"user", string(content)},
+		},
+	}
+	if !lt.options.NoJSONMode {
+		body["response_format"] = map[string]string{"type": "json_object"}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		lt.endpoint+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if lt.options.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+lt.options.APIKey)
+	}
+	httpResp, err := lt.client.Do(httpReq)
+	if err != nil {
+		return &RetryableError{Err: err}
+	}
+	defer httpResp.Body.Close()
+	data, err = ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return &RetryableError{Err: err}
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return llmError(httpResp.StatusCode, data)
+	}
+	var completion struct {
+		Choices []struct {
+			Message      message `json:"message"`
+			FinishReason string  `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	err = json.Unmarshal(data, &completion)
+	if err != nil {
+		return fmt.Errorf("unpacking got %v", err)
+	}
+	if len(completion.Choices) == 0 {
+		return &RetryableError{Err: fmt.Errorf("no answer")}
+	}
+	choice := completion.Choices[0]
+	if choice.FinishReason == "length" {
+		return fmt.Errorf("answer cut off, use smaller batches")
+	}
+	err = json.Unmarshal([]byte(llmJSON(choice.Message.Content)), resp)
+	if err != nil {
+		return &RetryableError{Err: fmt.Errorf("unpacking answer got %v", err)}
+	}
+	return nil
+}
+
+// llmJSON returns the JSON in content, without the ``` fences models
+// sometimes put around it.
+func llmJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimPrefix(content, "json")
+		content = strings.TrimSuffix(content, "```")
+	}
+	return strings.TrimSpace(content)
+}
+
+// llmError returns the error for an API status, marking too many requests
+// and availability problems as retryable.
+func llmError(statusCode int, data []byte) error {
+	var message struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.Unmarshal(data, &message)
+	err := fmt.Errorf("%d %s %s", statusCode, http.StatusText(statusCode), message.Error.Message)
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return &RetryableError{Err: err}
+	}
+	return err
+}
+
+// BatchLimits returns the request limits.
+func (lt *LLMTranslator) BatchLimits() BatchLimits {
+	if lt.options.Limits != nil {
+		return *lt.options.Limits
+	}
+	return BatchLimits{
+		Segments:   LLM_MAX_SEGMENTS,
+		CodePoints: LLM_MAX_CODE_POINTS,
+	}
+}
+
+// EngineName returns the engine name and, as the model, the model and
+// server, and mime type.
+func (lt *LLMTranslator) EngineName() (string, string) {
+	model := lt.options.Model
+	if lt.endpoint != LLM_ENDPOINT {
+		model += " " + lt.endpoint
+	}
+	if lt.options.MimeType != MIME_TEXT {
+		model += " " + lt.options.MimeType
+	}
+	return "openai", model
+}
+
+// Close does nothing.
+func (lt *LLMTranslator) Close() error {
+	return nil
+}
@@ -0,0 +1,45 @@
+// notes.go
+// Notes give translators the context of short mainLang lines, such as
+// whether "Open" is a verb or an adjective.
+package translate
+
+import (
+	"fmt"
+	"os"
+	"path"
+)
+
+const NOTES_SUFFIX = ".notes"
+
+// NotesFilename returns the path of the notes file for the given language.
+// It is meaning ordered like the words file with an empty line for a line
+// without a note.
+func NotesFilename(wordsDir, lang string) string {
+	return path.Join(wordsDir, lang+NOTES_SUFFIX)
+}
+
+// WordsGetNotes returns the notes for the lines of lang.  If there are none
+// it returns nil.  Missing lines at the end of the notes file have no
+// note, extra lines are an error.
+func WordsGetNotes(wordsDir, lang string) ([]string, error) {
+	filename := NotesFilename(wordsDir, lang)
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, nil
+	}
+	notes, err := readLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	words, err := WordsGetWords(wordsDir, lang)
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) > len(words) {
+		return nil, fmt.Errorf("%s has %d notes for %d words",
+			filename, len(notes), len(words))
+	}
+	for len(notes) < len(words) {
+		notes = append(notes, "")
+	}
+	return notes, nil
+}
@@ -0,0 +1,83 @@
+// placeholders.go
+// Protecting placeholders and markup from translation.
+package translate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	// placeholderRe matches printf verbs (%s, %1$d), braced names ({name},
+	// {0}), environment references ($(APP_NAME)), HTML tags and entities.
+	placeholderRe = regexp.MustCompile(
+		`%(?:\d+\$)?[-+#0]*\d*(?:\.\d+)?[a-zA-Z%]` +
+			`|\{[A-Za-z0-9_.]*\}` +
+			`|\$\([A-Za-z0-9_]+\)` +
+			`|</?[A-Za-z][^<>]*>` +
+			`|&(?:[A-Za-z]+|#[0-9]+);`)
+	// maskRe matches the tokens placeholders are masked with, allowing for
+	// spaces added by the translator.
+	maskRe = regexp.MustCompile(`⟦\s*(\d+)\s*⟧`)
+)
+
+// Placeholders returns the placeholders and markup in text.
+func Placeholders(text string) []string {
+	return placeholderRe.FindAllString(text, -1)
+}
+
+// MaskPlaceholders replaces the placeholders and markup in text with
+// numbered tokens a translator will leave alone.  It returns the masked
+// text and the placeholders to give UnmaskPlaceholders.
+func MaskPlaceholders(text string) (string, []string) {
+	var placeholders []string
+	masked := placeholderRe.ReplaceAllStringFunc(text, func(placeholder string) string {
+		placeholders = append(placeholders, placeholder)
+		return fmt.Sprintf("⟦%d⟧", len(placeholders)-1)
+	})
+	return masked, placeholders
+}
+
+// UnmaskPlaceholders puts back the placeholders masked by
+// MaskPlaceholders.  Unknown tokens are left as they are.
+func UnmaskPlaceholders(text string, placeholders []string) string {
+	return maskRe.ReplaceAllStringFunc(text, func(token string) string {
+		i, err := strconv.Atoi(maskRe.FindStringSubmatch(token)[1])
+		if err != nil || i >= len(placeholders) {
+			return token
+		}
+		return placeholders[i]
+	})
+}
+
+// PlaceholdersMatch returns whether translation has the same placeholders
+// as source, in any order.
+func PlaceholdersMatch(source, translation string) bool {
+	want := Placeholders(source)
+	got := Placeholders(translation)
+	if len(want) != len(got) || maskRe.MatchString(translation) {
+		return false
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	for i := range want {
+		if want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// WordsCheckPlaceholders returns the lines in wordsDir whose placeholders
+// differ from those of the same line in mainLang.
+func WordsCheckPlaceholders(wordsDir, mainLang string) ([]WordsProblem, error) {
+	return wordsCheckLines(wordsDir, mainLang, func(lang, source, translation string) string {
+		if PlaceholdersMatch(source, translation) {
+			return ""
+		}
+		return fmt.Sprintf("placeholders %q expected %q",
+			Placeholders(translation), Placeholders(source))
+	})
+}
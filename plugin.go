@@ -0,0 +1,285 @@
+// plugin.go
+// Translator using an external program, so engines can be added without
+// changing this module.
+package translate
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// PLUGIN_INFO_TIMEOUT is how long a plugin has to answer info before it is
+// taken to have nothing to say.
+const PLUGIN_INFO_TIMEOUT = 2 * time.Second
+
+// pluginRequest is a request to a plugin.
+type pluginRequest struct {
+	ID          int      `json:"id"`
+	Method      string   `json:"method"`
+	Source      string   `json:"source,omitempty"`
+	Target      string   `json:"target,omitempty"`
+	DisplayLang string   `json:"displayLang,omitempty"`
+	Texts       []string `json:"texts,omitempty"`
+	Notes       []string `json:"notes,omitempty"`
+}
+
+// pluginResponse is the answer to a pluginRequest.
+type pluginResponse struct {
+	ID           int      `json:"id"`
+	Error        string   `json:"error,omitempty"`
+	Retryable    bool     `json:"retryable,omitempty"`
+	Translations []string `json:"translations,omitempty"`
+	Languages    []struct {
+		Code string `json:"code"`
+		Name string `json:"name"`
+	} `json:"languages,omitempty"`
+	Detections []struct {
+		Code       string  `json:"code"`
+		Confidence float32 `json:"confidence"`
+	} `json:"detections,omitempty"`
+	Engine string `json:"engine,omitempty"`
+	Model  string `json:"model,omitempty"`
+	Limits struct {
+		Segments   int `json:"segments"`
+		CodePoints int `json:"codePoints"`
+	} `json:"limits"`
+}
+
+// PluginTranslator is a Translator, and NotesTranslator, running an
+// external program.  Requests are sent one at a time.
+//
+// The program is started once and sent requests, each a JSON object on
+// one line, on its standard input.  It answers each with a JSON object on
+// one line on its standard output, in order.  Its standard error is passed
+// through for logging.  The program exits when its standard input is
+// closed.
+//
+// Every request has an "id", echoed in the answer, and a "method":
+//
+//	{"id":1,"method":"info"}
+//	{"id":2,"method":"translate","source":"en","target":"de",
+//		"texts":["Open"],"notes":["verb, a menu item"]}
+//	{"id":3,"method":"supported","displayLang":"en"}
+//	{"id":4,"method":"detect","texts":["Hallo"]}
+//
+// notes is only sent if there are notes.  The answers are:
+//
+//	{"id":1,"engine":"inhouse","model":"v2","limits":{"segments":50,"codePoints":0}}
+//	{"id":2,"translations":["Öffnen"]}
+//	{"id":3,"languages":[{"code":"de","name":"German"}]}
+//	{"id":4,"detections":[{"code":"de","confidence":0.9}]}
+//
+// A failed request is answered with "error", and "retryable":true if it
+// may succeed if tried again later:
+//
+//	{"id":2,"error":"quota exceeded","retryable":true}
+//
+// Answering info is optional, an error, an empty answer or none within
+// PLUGIN_INFO_TIMEOUT uses the command as the engine name and no limits.
+type PluginTranslator struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses chan *pluginResponse
+	done      chan struct{} // Closed when the program's output ends.
+	readErr   error         // Why the output ended, set before done.
+	mu        sync.Mutex    // One request at a time.
+	id        int
+	engine    string
+	model     string
+	limits    BatchLimits
+}
+
+// NewPluginTranslator starts the program name with args and asks it for
+// its engine name and limits.  Close it to stop the program.
+func NewPluginTranslator(ctx context.Context, name string, args ...string) (*PluginTranslator, error) {
+	pt := &PluginTranslator{
+		cmd:       exec.Command(name, args...),
+		responses: make(chan *pluginResponse),
+		done:      make(chan struct{}),
+		engine:    name,
+	}
+	pt.cmd.Stderr = os.Stderr
+	var err error
+	pt.stdin, err = pt.cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s got %v", name, err)
+	}
+	stdout, err := pt.cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s got %v", name, err)
+	}
+	err = pt.cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("starting plugin %s got %v", name, err)
+	}
+	go pt.read(stdout)
+	infoCtx, cancel := context.WithTimeout(ctx, PLUGIN_INFO_TIMEOUT)
+	resp, err := pt.call(infoCtx, &pluginRequest{Method: "info"})
+	cancel()
+	if err != nil {
+		_, answered := err.(*pluginError)
+		if !answered && (ctx.Err() != nil || err != context.DeadlineExceeded) {
+			pt.Close()
+			return nil, err
+		}
+		return pt, nil // info is optional.
+	}
+	if resp.Engine != "" {
+		pt.engine = resp.Engine
+	}
+	pt.model = resp.Model
+	pt.limits = BatchLimits{
+		Segments:   resp.Limits.Segments,
+		CodePoints: resp.Limits.CodePoints,
+	}
+	return pt, nil
+}
+
+// read passes the answers from the program to call until its output ends.
+func (pt *PluginTranslator) read(stdout io.Reader) {
+	defer close(pt.done)
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		var resp pluginResponse
+		err := json.Unmarshal(scanner.Bytes(), &resp)
+		if err != nil {
+			pt.readErr = fmt.Errorf("plugin answer got %v", err)
+			return
+		}
+		pt.responses <- &resp
+	}
+	pt.readErr = scanner.Err()
+	if pt.readErr == nil {
+		pt.readErr = fmt.Errorf("plugin %s exited", pt.cmd.Path)
+	}
+}
+
+// pluginError is an error answered by the program.
+type pluginError struct {
+	message string
+}
+
+func (pe *pluginError) Error() string {
+	return pe.message
+}
+
+// call sends req and returns its answer.  Answers to earlier requests
+// given up on when their ctx was done are skipped.
+func (pt *PluginTranslator) call(ctx context.Context, req *pluginRequest) (*pluginResponse, error) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.id++
+	req.ID = pt.id
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	_, err = pt.stdin.Write(append(data, '\n'))
+	if err != nil {
+		return nil, fmt.Errorf("plugin request got %v", err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-pt.done:
+			return nil, pt.readErr
+		case resp := <-pt.responses:
+			if resp.ID != req.ID {
+				continue
+			}
+			if resp.Error != "" {
+				var err error = &pluginError{resp.Error}
+				if resp.Retryable {
+					err = &RetryableError{Err: err}
+				}
+				return nil, err
+			}
+			return resp, nil
+		}
+	}
+}
+
+// Translate texts from source to target.
+func (pt *PluginTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	return pt.TranslateNotes(ctx, source, target, texts, nil)
+}
+
+// TranslateNotes translates texts from source to target sending the
+// notes, if not nil, with them.
+func (pt *PluginTranslator) TranslateNotes(ctx context.Context, source, target string, texts, notes []string) ([]string, error) {
+	resp, err := pt.call(ctx, &pluginRequest{
+		Method: "translate",
+		Source: source,
+		Target: target,
+		Texts:  texts,
+		Notes:  notes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("translate got %w", err)
+	}
+	return resp.Translations, nil
+}
+
+// Supported returns the languages of the program named in displayLang.
+func (pt *PluginTranslator) Supported(ctx context.Context, displayLang string) ([]Language, error) {
+	resp, err := pt.call(ctx, &pluginRequest{Method: "supported", DisplayLang: displayLang})
+	if err != nil {
+		return nil, fmt.Errorf("supported languages got %w", err)
+	}
+	langs := make([]Language, len(resp.Languages))
+	for i, lang := range resp.Languages {
+		langs[i] = Language{Code: lang.Code, Name: lang.Name}
+	}
+	return langs, nil
+}
+
+// Detect the language of each text.
+func (pt *PluginTranslator) Detect(ctx context.Context, texts []string) ([]Detection, error) {
+	resp, err := pt.call(ctx, &pluginRequest{Method: "detect", Texts: texts})
+	if err != nil {
+		return nil, fmt.Errorf("detect language got %w", err)
+	}
+	detections := make([]Detection, len(resp.Detections))
+	for i, detection := range resp.Detections {
+		detections[i] = Detection{Code: detection.Code, Confidence: detection.Confidence}
+	}
+	return detections, nil
+}
+
+// BatchLimits returns the limits the program answered info with.
+func (pt *PluginTranslator) BatchLimits() BatchLimits {
+	return pt.limits
+}
+
+// EngineName returns the names the program answered info with.
+func (pt *PluginTranslator) EngineName() (string, string) {
+	return pt.engine, pt.model
+}
+
+// Close closes the program's input and waits for it to exit.
+func (pt *PluginTranslator) Close() error {
+	pt.stdin.Close()
+	// Drop any answers not waited for until the output ends.
+	for {
+		select {
+		case <-pt.responses:
+			continue
+		case <-pt.done:
+		}
+		break
+	}
+	err := pt.cmd.Wait()
+	if err != nil {
+		return fmt.Errorf("plugin %s got %v", pt.engine, err)
+	}
+	return nil
+}
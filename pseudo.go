@@ -0,0 +1,275 @@
+// pseudo.go
+// Pseudo-localization Translator for testing, needing no network.
+package translate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// PSEUDO_EXPANSION is how much longer, as a fraction, pseudo-localized
+	// texts are made by default.
+	PSEUDO_EXPANSION = 0.3
+	// Pseudo-locale regions: accented and expanded, and mirrored bidi.
+	PSEUDO_ACCENTED = "XA"
+	PSEUDO_BIDI     = "XB"
+)
+
+// pseudoAccents maps ASCII letters to accented look-alikes.
+var pseudoAccents = map[rune]rune{
+	'a': 'á', 'b': 'ƀ', 'c': 'ç', 'd': 'ð', 'e': 'é', 'f': 'ƒ', 'g': 'ĝ',
+	'h': 'ĥ', 'i': 'î', 'j': 'ĵ', 'k': 'ķ', 'l': 'ļ', 'm': 'ɱ', 'n': 'ñ',
+	'o': 'ö', 'p': 'þ', 'q': 'ǫ', 'r': 'ŕ', 's': 'š', 't': 'ţ', 'u': 'û',
+	'v': 'ṽ', 'w': 'ŵ', 'x': 'ẋ', 'y': 'ý', 'z': 'ž',
+	'A': 'Å', 'B': 'Ɓ', 'C': 'Ç', 'D': 'Ð', 'E': 'É', 'F': 'Ƒ', 'G': 'Ĝ',
+	'H': 'Ĥ', 'I': 'Î', 'J': 'Ĵ', 'K': 'Ķ', 'L': 'Ļ', 'M': 'Ṁ', 'N': 'Ñ',
+	'O': 'Ö', 'P': 'Þ', 'Q': 'Ǫ', 'R': 'Ŕ', 'S': 'Š', 'T': 'Ţ', 'U': 'Û',
+	'V': 'Ṽ', 'W': 'Ŵ', 'X': 'Ẋ', 'Y': 'Ý', 'Z': 'Ž',
+}
+
+// pseudoPadding is added, as far as needed, to expand accented texts.
+const pseudoPadding = " one two three four five six seven eight nine ten"
+
+// PseudoOptions are the optional settings for a PseudoTranslator.
+type PseudoOptions struct {
+	// Expansion is how much longer, as a fraction, accented texts are
+	// made.  0 means PSEUDO_EXPANSION, less than 0 no expansion.
+	Expansion float64
+	// NoMarkers leaves out the [ and ] put around each text to show where
+	// it has been cut off.
+	NoMarkers bool
+}
+
+// PseudoTranslator is a Translator making pseudo-locales from the source
+// text.  Targets in the XA region, such as en-XA, get accented and
+// expanded text.  Targets in the XB region, such as ar-XB, get the text
+// mirrored right to left.  Placeholders and markup are left alone.
+type PseudoTranslator struct {
+	options PseudoOptions
+}
+
+// NewPseudoTranslator returns a PseudoTranslator.  nil options uses the
+// defaults.
+func NewPseudoTranslator(options *PseudoOptions) *PseudoTranslator {
+	pt := &PseudoTranslator{}
+	if options != nil {
+		pt.options = *options
+	}
+	if pt.options.Expansion == 0 {
+		pt.options.Expansion = PSEUDO_EXPANSION
+	}
+	return pt
+}
+
+// IsPseudoLocale returns whether lang is a pseudo-locale such as en-XA or
+// ar-XB.
+func IsPseudoLocale(lang string) bool {
+	return pseudoRegion(lang) != ""
+}
+
+func pseudoRegion(lang string) string {
+	parts := strings.Split(lang, "-")
+	region := strings.ToUpper(parts[len(parts)-1])
+	if len(parts) > 1 && (region == PSEUDO_ACCENTED || region == PSEUDO_BIDI) {
+		return region
+	}
+	return ""
+}
+
+// Translate pseudo-localizes texts for target.
+func (pt *PseudoTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	region := pseudoRegion(target)
+	if region == "" {
+		return nil, fmt.Errorf("%s is not a pseudo-locale (-%s or -%s)",
+			target, PSEUDO_ACCENTED, PSEUDO_BIDI)
+	}
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		if region == PSEUDO_ACCENTED {
+			translated[i] = pt.accent(text)
+		} else {
+			translated[i] = pt.mirror(text)
+		}
+		if !pt.options.NoMarkers {
+			translated[i] = "[" + translated[i] + "]"
+		}
+	}
+	return translated, nil
+}
+
+// pseudoText calls change with each part of text that is not a
+// placeholder or masked token.
+func pseudoText(text string, change func(string) string) string {
+	var b strings.Builder
+	start := 0
+	for _, loc := range pseudoKeep(text) {
+		b.WriteString(change(text[start:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		start = loc[1]
+	}
+	b.WriteString(change(text[start:]))
+	return b.String()
+}
+
+// pseudoKeep returns the locations of the placeholders and masked tokens
+// in text, in order.
+func pseudoKeep(text string) [][]int {
+	var keep [][]int
+	placeholders := placeholderRe.FindAllStringIndex(text, -1)
+	tokens := maskRe.FindAllStringIndex(text, -1)
+	for len(placeholders) != 0 || len(tokens) != 0 {
+		var next []int
+		if len(tokens) == 0 || len(placeholders) != 0 && placeholders[0][0] < tokens[0][0] {
+			next, placeholders = placeholders[0], placeholders[1:]
+		} else {
+			next, tokens = tokens[0], tokens[1:]
+		}
+		if len(keep) == 0 || next[0] >= keep[len(keep)-1][1] {
+			keep = append(keep, next)
+		}
+	}
+	return keep
+}
+
+// accent replaces letters with accented ones and pads the text out by the
+// expansion.
+func (pt *PseudoTranslator) accent(text string) string {
+	accented := pseudoText(text, func(part string) string {
+		return strings.Map(func(r rune) rune {
+			if accent, ok := pseudoAccents[r]; ok {
+				return accent
+			}
+			return r
+		}, part)
+	})
+	if pt.options.Expansion < 0 {
+		return accented
+	}
+	pad := int(math.Ceil(float64(utf8.RuneCountInString(text)) * pt.options.Expansion))
+	padding := []rune(strings.Repeat(pseudoPadding, pad/len(pseudoPadding)+1))
+	return accented + string(padding[:pad])
+}
+
+// mirror makes each word of text read right to left.
+func (pt *PseudoTranslator) mirror(text string) string {
+	return pseudoText(text, func(part string) string {
+		var b strings.Builder
+		inWord := false
+		for _, r := range part {
+			letter := unicode.IsLetter(r) || unicode.IsDigit(r)
+			if letter && !inWord {
+				b.WriteString("\u200f\u202e") // RLM, RLO.
+			} else if !letter && inWord {
+				b.WriteString("\u202c\u200f") // PDF, RLM.
+			}
+			inWord = letter
+			b.WriteRune(r)
+		}
+		if inWord {
+			b.WriteString("\u202c\u200f")
+		}
+		return b.String()
+	})
+}
+
+// Supported returns the example pseudo-locales, any language in the XA or
+// XB region works.
+func (pt *PseudoTranslator) Supported(ctx context.Context, displayLang string) ([]Language, error) {
+	return []Language{
+		{Code: "en-" + PSEUDO_ACCENTED, Name: "English (pseudo-accented)"},
+		{Code: "ar-" + PSEUDO_BIDI, Name: "Arabic (pseudo-bidi)"},
+	}, nil
+}
+
+// Detect cannot tell languages apart, it detects nothing.
+func (pt *PseudoTranslator) Detect(ctx context.Context, texts []string) ([]Detection, error) {
+	return make([]Detection, len(texts)), nil
+}
+
+// EngineName returns the engine name and the options as the model.
+func (pt *PseudoTranslator) EngineName() (string, string) {
+	return "pseudo", fmt.Sprintf("expansion %g markers %v",
+		pt.options.Expansion, !pt.options.NoMarkers)
+}
+
+// Close does nothing.
+func (pt *PseudoTranslator) Close() error {
+	return nil
+}
+
+// PseudoRouter is a Translator sending pseudo-locale targets to a
+// PseudoTranslator and the rest to another Translator.
+type PseudoRouter struct {
+	Translator
+	Pseudo *PseudoTranslator
+}
+
+// NewPseudoRouter returns a PseudoRouter sending pseudo-locales to pseudo
+// and the rest to translator, as a PseudoNotesRouter if translator is a
+// NotesTranslator.
+func NewPseudoRouter(translator Translator, pseudo *PseudoTranslator) Translator {
+	router := &PseudoRouter{Translator: translator, Pseudo: pseudo}
+	if _, ok := translator.(NotesTranslator); ok {
+		return &PseudoNotesRouter{router}
+	}
+	return router
+}
+
+// Translate texts using the PseudoTranslator for pseudo-locales.
+func (pr *PseudoRouter) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	if IsPseudoLocale(target) {
+		return pr.Pseudo.Translate(ctx, source, target, texts)
+	}
+	return pr.Translator.Translate(ctx, source, target, texts)
+}
+
+// BatchLimits returns the limits of the other Translator.
+func (pr *PseudoRouter) BatchLimits() BatchLimits {
+	return TranslatorBatchLimits(pr.Translator)
+}
+
+// DetectBatchLimits returns the detect limits of the other Translator.
+func (pr *PseudoRouter) DetectBatchLimits() BatchLimits {
+	return TranslatorDetectBatchLimits(pr.Translator)
+}
+
+// EngineName returns the names of the other Translator.
+func (pr *PseudoRouter) EngineName() (string, string) {
+	return TranslatorEngine(pr.Translator)
+}
+
+// TargetEngineName returns the names of the PseudoTranslator for
+// pseudo-locales and of the other Translator for the rest.
+func (pr *PseudoRouter) TargetEngineName(target string) (string, string) {
+	if IsPseudoLocale(target) {
+		return pr.Pseudo.EngineName()
+	}
+	return TranslatorTargetEngine(pr.Translator, target)
+}
+
+// AppliesGlossary returns whether the other Translator applies a glossary
+// for target.
+func (pr *PseudoRouter) AppliesGlossary(source, target string) bool {
+	return !IsPseudoLocale(target) &&
+		TranslatorAppliesGlossary(pr.Translator, source, target)
+}
+
+// PseudoNotesRouter is a PseudoRouter whose other Translator is a
+// NotesTranslator.
+type PseudoNotesRouter struct {
+	*PseudoRouter
+}
+
+// TranslateNotes translates texts like Translate, passing the notes on
+// for targets that are not pseudo-locales.
+func (pr *PseudoNotesRouter) TranslateNotes(ctx context.Context, source, target string, texts, notes []string) ([]string, error) {
+	if nt, ok := pr.Translator.(NotesTranslator); ok && !IsPseudoLocale(target) {
+		return nt.TranslateNotes(ctx, source, target, texts, notes)
+	}
+	return pr.Translate(ctx, source, target, texts)
+}
@@ -0,0 +1,327 @@
+// replay.go
+// Recording a Translator's requests to a cassette file and replaying them
+// offline.
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// Cassette is what a RecordTranslator saves and a ReplayTranslator
+// serves: the recorded Translator's names and limits and its answers to
+// each request.
+type Cassette struct {
+	Engine   string              `json:"engine"`
+	Model    string              `json:"model,omitempty"`
+	Limits   BatchLimits         `json:"limits"`
+	Detect   BatchLimits         `json:"detectLimits"`       // Limits of detect, Limits if zero.
+	Glossary map[string]bool     `json:"glossary,omitempty"` // By "source>target".
+	Targets  map[string][]string `json:"targets,omitempty"`  // Other engine and model by target.
+	Requests []CassetteRequest   `json:"requests"`
+}
+
+// CassetteRequest is one recorded request and its answer.
+type CassetteRequest struct {
+	Method       string      `json:"method"` // translate, supported or detect.
+	Source       string      `json:"source,omitempty"`
+	Target       string      `json:"target,omitempty"`
+	DisplayLang  string      `json:"displayLang,omitempty"`
+	Texts        []string    `json:"texts,omitempty"`
+	Notes        []string    `json:"notes,omitempty"`
+	Translations []string    `json:"translations,omitempty"`
+	Languages    []Language  `json:"languages,omitempty"`
+	Detections   []Detection `json:"detections,omitempty"`
+}
+
+// key identifies the request, not its answer.
+func (cr *CassetteRequest) key() string {
+	key, _ := json.Marshal([]interface{}{
+		cr.Method, cr.Source, cr.Target, cr.DisplayLang, cr.Texts, cr.Notes})
+	return string(key)
+}
+
+// ReadCassette reads a cassette from filename.
+func ReadCassette(filename string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette got %v", err)
+	}
+	var cassette Cassette
+	err = json.Unmarshal(data, &cassette)
+	if err != nil {
+		return nil, fmt.Errorf("unpacking cassette %s got %v", filename, err)
+	}
+	return &cassette, nil
+}
+
+// Write the cassette to filename.
+func (c *Cassette) Write(filename string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("packing cassette got %v", err)
+	}
+	return writeLines(filename, []string{string(data)})
+}
+
+// RecordTranslator is a Translator passing requests to another Translator
+// and recording the successful ones.  The cassette is written by Close.
+type RecordTranslator struct {
+	translator Translator
+	filename   string
+	mu         sync.Mutex
+	cassette   Cassette
+	recorded   map[string]bool
+}
+
+// NewRecordTranslator returns a RecordTranslator recording translator's
+// answers to the cassette filename.  Closing it closes translator.
+func NewRecordTranslator(translator Translator, filename string) *RecordTranslator {
+	engine, model := TranslatorEngine(translator)
+	return &RecordTranslator{
+		translator: translator,
+		filename:   filename,
+		cassette: Cassette{
+			Engine:   engine,
+			Model:    model,
+			Limits:   TranslatorBatchLimits(translator),
+			Detect:   TranslatorDetectBatchLimits(translator),
+			Glossary: make(map[string]bool),
+			Targets:  make(map[string][]string),
+		},
+		recorded: make(map[string]bool),
+	}
+}
+
+func (rt *RecordTranslator) record(request CassetteRequest) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	key := request.key()
+	if rt.recorded[key] {
+		return
+	}
+	rt.recorded[key] = true
+	rt.cassette.Requests = append(rt.cassette.Requests, request)
+}
+
+// Translate passes the request on and records the answer.
+func (rt *RecordTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	return rt.TranslateNotes(ctx, source, target, texts, nil)
+}
+
+// TranslateNotes passes the request on, with the notes if the Translator
+// uses them, and records the answer with the notes so replaying matches
+// them.
+func (rt *RecordTranslator) TranslateNotes(ctx context.Context, source, target string, texts, notes []string) ([]string, error) {
+	var translated []string
+	var err error
+	if nt, ok := rt.translator.(NotesTranslator); ok && notes != nil {
+		translated, err = nt.TranslateNotes(ctx, source, target, texts, notes)
+	} else {
+		translated, err = rt.translator.Translate(ctx, source, target, texts)
+	}
+	if err == nil {
+		rt.record(CassetteRequest{
+			Method:       "translate",
+			Source:       source,
+			Target:       target,
+			Texts:        append([]string(nil), texts...),
+			Notes:        append([]string(nil), notes...),
+			Translations: append([]string(nil), translated...),
+		})
+	}
+	return translated, err
+}
+
+// Supported passes the request on and records the answer.
+func (rt *RecordTranslator) Supported(ctx context.Context, displayLang string) ([]Language, error) {
+	langs, err := rt.translator.Supported(ctx, displayLang)
+	if err == nil {
+		rt.record(CassetteRequest{
+			Method:      "supported",
+			DisplayLang: displayLang,
+			Languages:   langs,
+		})
+	}
+	return langs, err
+}
+
+// Detect passes the request on and records the answer.
+func (rt *RecordTranslator) Detect(ctx context.Context, texts []string) ([]Detection, error) {
+	detections, err := rt.translator.Detect(ctx, texts)
+	if err == nil {
+		rt.record(CassetteRequest{
+			Method:     "detect",
+			Texts:      append([]string(nil), texts...),
+			Detections: detections,
+		})
+	}
+	return detections, err
+}
+
+// BatchLimits returns the limits of the recorded Translator.
+func (rt *RecordTranslator) BatchLimits() BatchLimits {
+	return rt.cassette.Limits
+}
+
+// DetectBatchLimits returns the detect limits of the recorded Translator.
+func (rt *RecordTranslator) DetectBatchLimits() BatchLimits {
+	return rt.cassette.Detect
+}
+
+// EngineName returns the names of the recorded Translator.
+func (rt *RecordTranslator) EngineName() (string, string) {
+	return rt.cassette.Engine, rt.cassette.Model
+}
+
+// TargetEngineName returns, and records if they differ from EngineName, the
+// names the recorded Translator uses for target.
+func (rt *RecordTranslator) TargetEngineName(target string) (string, string) {
+	engine, model := TranslatorTargetEngine(rt.translator, target)
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if engine != rt.cassette.Engine || model != rt.cassette.Model {
+		rt.cassette.Targets[target] = []string{engine, model}
+	}
+	return engine, model
+}
+
+// AppliesGlossary returns, and records, whether the recorded Translator
+// applies a glossary.
+func (rt *RecordTranslator) AppliesGlossary(source, target string) bool {
+	applies := TranslatorAppliesGlossary(rt.translator, source, target)
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if applies {
+		rt.cassette.Glossary[source+">"+target] = true
+	}
+	return applies
+}
+
+// Close writes the cassette and closes the recorded Translator.
+func (rt *RecordTranslator) Close() error {
+	rt.mu.Lock()
+	err := rt.cassette.Write(rt.filename)
+	rt.mu.Unlock()
+	closeErr := rt.translator.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ReplayTranslator is a Translator answering from a cassette, without
+// calling any service.  A request not in the cassette fails.
+type ReplayTranslator struct {
+	cassette *Cassette
+	requests map[string]*CassetteRequest
+}
+
+// NewReplayTranslator returns a ReplayTranslator for the cassette
+// filename.
+func NewReplayTranslator(filename string) (*ReplayTranslator, error) {
+	cassette, err := ReadCassette(filename)
+	if err != nil {
+		return nil, err
+	}
+	rt := &ReplayTranslator{
+		cassette: cassette,
+		requests: make(map[string]*CassetteRequest),
+	}
+	for i := range cassette.Requests {
+		request := &cassette.Requests[i]
+		rt.requests[request.key()] = request
+	}
+	return rt, nil
+}
+
+func (rt *ReplayTranslator) replay(request CassetteRequest) (*CassetteRequest, error) {
+	recorded, ok := rt.requests[request.key()]
+	if !ok {
+		what := strings.Join(request.Texts, "|")
+		if request.Method == "supported" {
+			what = request.DisplayLang
+		}
+		return nil, fmt.Errorf("unexpected %s %s>%s request %q not in cassette",
+			request.Method, request.Source, request.Target, what)
+	}
+	return recorded, nil
+}
+
+// Translate answers from the cassette.
+func (rt *ReplayTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	return rt.TranslateNotes(ctx, source, target, texts, nil)
+}
+
+// TranslateNotes answers from the cassette, matching the notes recorded.
+func (rt *ReplayTranslator) TranslateNotes(ctx context.Context, source, target string, texts, notes []string) ([]string, error) {
+	recorded, err := rt.replay(CassetteRequest{
+		Method: "translate", Source: source, Target: target, Texts: texts, Notes: notes})
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), recorded.Translations...), nil
+}
+
+// Supported answers from the cassette.
+func (rt *ReplayTranslator) Supported(ctx context.Context, displayLang string) ([]Language, error) {
+	recorded, err := rt.replay(CassetteRequest{
+		Method: "supported", DisplayLang: displayLang})
+	if err != nil {
+		return nil, err
+	}
+	return recorded.Languages, nil
+}
+
+// Detect answers from the cassette.
+func (rt *ReplayTranslator) Detect(ctx context.Context, texts []string) ([]Detection, error) {
+	recorded, err := rt.replay(CassetteRequest{Method: "detect", Texts: texts})
+	if err != nil {
+		return nil, err
+	}
+	return recorded.Detections, nil
+}
+
+// BatchLimits returns the limits of the recorded Translator so requests
+// are batched as they were when recorded.
+func (rt *ReplayTranslator) BatchLimits() BatchLimits {
+	return rt.cassette.Limits
+}
+
+// DetectBatchLimits returns the detect limits of the recorded Translator.
+// Cassettes recorded without them used the BatchLimits.
+func (rt *ReplayTranslator) DetectBatchLimits() BatchLimits {
+	if rt.cassette.Detect == (BatchLimits{}) {
+		return rt.cassette.Limits
+	}
+	return rt.cassette.Detect
+}
+
+// EngineName returns the names of the recorded Translator.
+func (rt *ReplayTranslator) EngineName() (string, string) {
+	return rt.cassette.Engine, rt.cassette.Model
+}
+
+// TargetEngineName returns the names the recorded Translator used for
+// target.
+func (rt *ReplayTranslator) TargetEngineName(target string) (string, string) {
+	if names := rt.cassette.Targets[target]; len(names) == 2 {
+		return names[0], names[1]
+	}
+	return rt.EngineName()
+}
+
+// AppliesGlossary returns whether the recorded Translator applied a
+// glossary.
+func (rt *ReplayTranslator) AppliesGlossary(source, target string) bool {
+	return rt.cassette.Glossary[source+">"+target]
+}
+
+// Close does nothing.
+func (rt *ReplayTranslator) Close() error {
+	return nil
+}
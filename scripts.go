@@ -0,0 +1,262 @@
+// scripts.go
+// Checking that the lines of words files are written in the scripts of
+// their language, to find lines left untranslated or in another language
+// without calling any API.
+package translate
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// languageScripts are the Unicode scripts, by unicode.Scripts name, that
+// each language is written in.  Languages not listed are not checked.
+var languageScripts = map[string][]string{
+	"am": {"Ethiopic"},
+	"ar": {"Arabic"},
+	"as": {"Bengali"},
+	"be": {"Cyrillic"},
+	"bg": {"Cyrillic"},
+	"bn": {"Bengali"},
+	"bo": {"Tibetan"},
+	"el": {"Greek"},
+	"fa": {"Arabic"},
+	"gu": {"Gujarati"},
+	"he": {"Hebrew"},
+	"hi": {"Devanagari"},
+	"hy": {"Armenian"},
+	"ja": {"Han", "Hiragana", "Katakana"},
+	"ka": {"Georgian"},
+	"kk": {"Cyrillic"},
+	"km": {"Khmer"},
+	"kn": {"Kannada"},
+	"ko": {"Hangul", "Han"},
+	"ky": {"Cyrillic"},
+	"lo": {"Lao"},
+	"mk": {"Cyrillic"},
+	"ml": {"Malayalam"},
+	"mn": {"Cyrillic"},
+	"mr": {"Devanagari"},
+	"my": {"Myanmar"},
+	"ne": {"Devanagari"},
+	"or": {"Oriya"},
+	"pa": {"Gurmukhi"},
+	"ps": {"Arabic"},
+	"ru": {"Cyrillic"},
+	"si": {"Sinhala"},
+	"sr": {"Cyrillic", "Latin"},
+	"ta": {"Tamil"},
+	"te": {"Telugu"},
+	"tg": {"Cyrillic"},
+	"th": {"Thai"},
+	"ti": {"Ethiopic"},
+	"uk": {"Cyrillic"},
+	"ur": {"Arabic"},
+	"yi": {"Hebrew"},
+	"zh": {"Han"},
+}
+
+// latinLanguages are the languages written in the Latin script.
+var latinLanguages = []string{
+	"af", "az", "ca", "cs", "cy", "da", "de", "en", "es", "et", "eu", "fi",
+	"fil", "fr", "ga", "gl", "ha", "hr", "hu", "id", "ig", "is", "it", "jv",
+	"lb", "lt", "lv", "mg", "mi", "ms", "mt", "nl", "no", "pl", "pt", "ro",
+	"sk", "sl", "sm", "so", "sq", "sv", "sw", "tl", "tr", "uz", "vi", "xh",
+	"yo", "zu",
+}
+
+func init() {
+	for _, lang := range latinLanguages {
+		languageScripts[lang] = []string{"Latin"}
+	}
+}
+
+// hanVariants pairs common Chinese characters only used in Simplified
+// Chinese with their Traditional forms.
+const hanVariants = "" +
+	"这這们們个個说說来來时時会會对對过過发發开開关關门門问問间間见見" +
+	"车車东東书書长長马馬鸟鳥鱼魚为為与與业業两兩丢丟优優体體网網页頁" +
+	"设設统統录錄语語请請输輸记記误誤错錯载載删刪节節闭閉帮幫选選择擇" +
+	"项項单單码碼创創务務资資讯訊动動态態实實现現应應无無线線电電脑腦" +
+	"图圖显顯权權钮鈕复復签簽认認证證报報级級帐帳"
+
+var simplifiedOnly, traditionalOnly = hanVariantSets()
+
+func hanVariantSets() (map[rune]bool, map[rune]bool) {
+	simplified := make(map[rune]bool)
+	traditional := make(map[rune]bool)
+	runes := []rune(hanVariants)
+	for i := 0; i+1 < len(runes); i += 2 {
+		simplified[runes[i]] = true
+		traditional[runes[i+1]] = true
+	}
+	return simplified, traditional
+}
+
+// LanguageScripts returns the names of the Unicode scripts lang is written
+// in, nil if it is not known.
+func LanguageScripts(lang string) []string {
+	return languageScripts[strings.ToLower(Iso639FromBcp47(lang))]
+}
+
+// hanTraditional returns whether the Chinese lang is written with
+// Traditional characters.
+func hanTraditional(lang string) bool {
+	for _, subtag := range strings.Split(strings.ToLower(lang), "-")[1:] {
+		switch subtag {
+		case "tw", "hk", "mo", "hant":
+			return true
+		case "hans":
+			return false
+		}
+	}
+	return false
+}
+
+// ScriptProblem returns what is wrong with the scripts of translation, a
+// line of lang, or "" if nothing is.  A line is wrong if most of its
+// letters are in scripts lang is not written in.  Placeholders and words
+// also in source, such as brand names, are not counted unless the line
+// has no letters in the scripts of lang at all.  Chinese lines are also
+// wrong if they have more Simplified than Traditional characters, or the
+// other way around, than expected.
+func ScriptProblem(lang, source, translation string) string {
+	scripts := LanguageScripts(lang)
+	if scripts == nil || IsPseudoLocale(lang) {
+		return ""
+	}
+	text := placeholderRe.ReplaceAllString(translation, " ")
+	expected := 0
+	other := make(map[string]int)  // Letters by script.
+	copied := make(map[string]int) // Letters in words also in source.
+	for _, run := range scriptRuns(text) {
+		if runeInScripts(run.letters[0], scripts) {
+			expected += len(run.letters)
+		} else if len(glossaryFind(source, run.word)) != 0 {
+			copied[run.script] += len(run.letters)
+		} else {
+			other[run.script] += len(run.letters)
+		}
+	}
+	if expected == 0 {
+		// Nothing translated, such as a line left in the source language.
+		for script, count := range copied {
+			other[script] += count
+		}
+	}
+	otherTotal := 0
+	for _, count := range other {
+		otherTotal += count
+	}
+	if otherTotal > expected {
+		return fmt.Sprintf("mostly %s letters, expected %s",
+			mostScript(other), strings.Join(scripts, " or "))
+	}
+	if strings.ToLower(Iso639FromBcp47(lang)) == "zh" {
+		simplified, traditional := 0, 0
+		for _, r := range translation {
+			if simplifiedOnly[r] {
+				simplified++
+			}
+			if traditionalOnly[r] {
+				traditional++
+			}
+		}
+		if hanTraditional(lang) && simplified > traditional {
+			return fmt.Sprintf("%d Simplified Chinese characters, expected Traditional", simplified)
+		}
+		if !hanTraditional(lang) && traditional > simplified {
+			return fmt.Sprintf("%d Traditional Chinese characters, expected Simplified", traditional)
+		}
+	}
+	return ""
+}
+
+// scriptRun is a run of letters in one script.
+type scriptRun struct {
+	word    string
+	script  string
+	letters []rune // Without the marks.
+}
+
+// scriptRuns splits text into runs of letters, and their marks, in the
+// same script.  Letters common to many scripts, like the Japanese ー, are
+// part of the run they are in but not counted.
+func scriptRuns(text string) []scriptRun {
+	var runs []scriptRun
+	start := -1
+	var current scriptRun
+	end := func(i int) {
+		if start >= 0 {
+			current.word = text[start:i]
+			runs = append(runs, current)
+		}
+		start = -1
+	}
+	for i, r := range text {
+		switch {
+		case unicode.IsLetter(r) && unicode.In(r, unicode.Common, unicode.Inherited):
+			// Part of the run, if any, without a script of its own.
+		case unicode.IsLetter(r):
+			script := runeScript(r)
+			if start < 0 || script != current.script {
+				end(i)
+				start = i
+				current = scriptRun{script: script}
+			}
+			current.letters = append(current.letters, r)
+		case unicode.IsMark(r) && start >= 0:
+			// Part of the letter before.
+		default:
+			end(i)
+		}
+	}
+	end(len(text))
+	return runs
+}
+
+// runeInScripts returns whether r is in one of the named scripts.
+func runeInScripts(r rune, scripts []string) bool {
+	for _, script := range scripts {
+		if table, ok := unicode.Scripts[script]; ok && unicode.Is(table, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// runeScript returns the name of the script of r, "unknown" if it is not
+// one of those languages are written in.
+func runeScript(r rune) string {
+	if unicode.Is(unicode.Latin, r) {
+		return "Latin"
+	}
+	for _, scripts := range languageScripts {
+		for _, script := range scripts {
+			if unicode.Is(unicode.Scripts[script], r) {
+				return script
+			}
+		}
+	}
+	return "unknown"
+}
+
+// mostScript returns the script with the highest count, by name on ties.
+func mostScript(counts map[string]int) string {
+	most := ""
+	for script, count := range counts {
+		if most == "" || count > counts[most] ||
+			count == counts[most] && script < most {
+			most = script
+		}
+	}
+	return most
+}
+
+// WordsCheckScripts returns the lines in wordsDir mostly written in scripts
+// other than those of their language, as found by ScriptProblem.
+// Languages whose scripts are not known are not checked.
+func WordsCheckScripts(wordsDir, mainLang string) ([]WordsProblem, error) {
+	return wordsCheckLines(wordsDir, mainLang, ScriptProblem)
+}
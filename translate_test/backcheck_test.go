@@ -0,0 +1,108 @@
+// Test back-translation checks.
+package translate_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestBackcheckScore(t *testing.T) {
+	var tests = []struct {
+		source, back string
+		score        float64
+	}{
+		{"Open", "open", 1},
+		{"Save file", "Save the file", 0.8},
+		{"Back", "Spine", 0},
+		{"Settings", "Setting", 7.0 / 8},
+	}
+	for _, test := range tests {
+		score := xlns.BackcheckScore(test.source, test.back)
+		if math.Abs(score-test.score) > 1e-9 {
+			t.Errorf("BackcheckScore(%q, %q) got %f expected %f", test.source, test.back, score, test.score)
+		}
+	}
+}
+
+// backTranslator translates from German with a fixed dictionary.
+type backTranslator struct {
+	fakeTranslator
+	sources []string // Languages translated from.
+}
+
+func (bt *backTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	bt.sources = append(bt.sources, source)
+	dictionary := map[string]string{
+		"Öffnen":          "Open",
+		"Rücken":          "Spine",
+		"Datei speichern": "Save the file",
+	}
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = dictionary[text]
+	}
+	return translated, nil
+}
+
+func TestXlnsBackcheck(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]string{
+		"en":    {"Open", "Back", "Save file"},
+		"de":    {"Öffnen", "Rücken", "Datei speichern"},
+		"en-XA": {"[Ööþéñ]", "[ßåçķ]", "[Šåvé ƒîļé]"},
+	}
+	for lang, words := range files {
+		err := xlns.WordsWriteWords(dir, lang, words)
+		if err != nil {
+			t.Fatalf("WordsWriteWords got %v", err)
+		}
+	}
+	bt := &backTranslator{}
+	flagged, err := xlns.XlnsBackcheck(context.Background(), dir, bt, "en", nil)
+	if err != nil {
+		t.Fatalf("XlnsBackcheck got %v", err)
+	}
+	if len(flagged) != 1 || flagged[0].Lang != "de" || flagged[0].Line != 2 ||
+		flagged[0].Back != "Spine" || flagged[0].Translation != "Rücken" {
+		t.Errorf("XlnsBackcheck got %v", flagged)
+	}
+	if len(bt.sources) != 1 || bt.sources[0] != "de" {
+		t.Errorf("translated from %q expected only de", bt.sources)
+	}
+	flagged, err = xlns.XlnsBackcheck(context.Background(), dir, bt, "en",
+		&xlns.BackcheckOptions{Score: 0.9, Langs: []string{"de"}})
+	if err != nil || len(flagged) != 2 {
+		t.Errorf("XlnsBackcheck at 0.9 got %v %v", flagged, err)
+	}
+	// The budget covers every language: the 27 characters of de fit but
+	// not with the same lines again as fr.
+	err = xlns.WordsWriteWords(dir, "fr", files["de"])
+	if err != nil {
+		t.Fatalf("WordsWriteWords got %v", err)
+	}
+	budget := &xlns.XlnsOptions{Budget: 30}
+	_, err = xlns.XlnsBackcheck(context.Background(), dir, bt, "en",
+		&xlns.BackcheckOptions{Langs: []string{"de"}, XlnsOptions: budget})
+	if err != nil {
+		t.Errorf("XlnsBackcheck of de within budget got %v", err)
+	}
+	_, err = xlns.XlnsBackcheck(context.Background(), dir, bt, "en",
+		&xlns.BackcheckOptions{Langs: []string{"de", "fr"}, XlnsOptions: budget})
+	var budgetErr *xlns.BudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Errorf("XlnsBackcheck of de and fr got %v expected a BudgetError", err)
+	}
+	// Files out of step are refused.
+	err = xlns.WordsWriteWords(dir, "de", files["de"][:2])
+	if err != nil {
+		t.Fatalf("WordsWriteWords got %v", err)
+	}
+	_, err = xlns.XlnsBackcheck(context.Background(), dir, bt, "en", nil)
+	if err == nil {
+		t.Errorf("XlnsBackcheck with a short de got no error")
+	}
+}
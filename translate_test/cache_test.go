@@ -0,0 +1,94 @@
+// Test the translation cache.
+package translate_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestXlnsCache(t *testing.T) {
+	dir := copyWords(t)
+	cacheFile := path.Join(t.TempDir(), xlns.CACHE_FILENAME)
+	cache, err := xlns.OpenXlnsCache(cacheFile)
+	if err != nil {
+		t.Fatalf("OpenXlnsCache got %v", err)
+	}
+	ft := &fakeTranslator{}
+	options := &xlns.XlnsOptions{Cache: cache}
+	err = xlns.XlnsAddTranslator(dir, ft, "en", []string{"de"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	cache.Close()
+	// Re-adding a deleted language costs nothing.
+	os.Remove(xlns.WordsFilename(dir, "de"))
+	cache, err = xlns.OpenXlnsCache(cacheFile)
+	if err != nil {
+		t.Fatalf("OpenXlnsCache got %v", err)
+	}
+	defer cache.Close()
+	ft = &fakeTranslator{}
+	options.Cache = cache
+	err = xlns.XlnsAddTranslator(dir, ft, "en", []string{"de"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	if ft.calls != 0 {
+		t.Errorf("cached add made %d calls", ft.calls)
+	}
+	words, _ := xlns.WordsGetWords(dir, "de")
+	if words[1] != "de: tests" {
+		t.Errorf("cached translation got %q", words[1])
+	}
+	// Prune the translation of a line no longer used, keeping those with
+	// notes of lines still used.
+	en, _ := xlns.WordsGetWords(dir, "en")
+	err = cache.Put("fake", "", "en", "de", []string{en[1] + "\x00a note"}, []string{"de: noted"})
+	if err != nil {
+		t.Fatalf("Put got %v", err)
+	}
+	xlns.WordsWriteWords(dir, "en", en[1:])
+	removed, err := cache.PruneUnused(dir)
+	if err != nil {
+		t.Fatalf("PruneUnused got %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("pruned %d expected 1", removed)
+	}
+	if len(cache.Entries()) != len(en) {
+		t.Errorf("%d entries left expected %d", len(cache.Entries()), len(en))
+	}
+}
+
+func TestReadXlnsCache(t *testing.T) {
+	cacheFile := path.Join(t.TempDir(), xlns.CACHE_FILENAME)
+	cache, err := xlns.ReadXlnsCache(cacheFile)
+	if err != nil {
+		t.Fatalf("ReadXlnsCache got %v", err)
+	}
+	if len(cache.Entries()) != 0 {
+		t.Errorf("missing cache got %d entries", len(cache.Entries()))
+	}
+	cache.Close()
+	if _, err := os.Stat(cacheFile); err == nil {
+		t.Errorf("ReadXlnsCache made %s", cacheFile)
+	}
+	writer, _ := xlns.OpenXlnsCache(cacheFile)
+	writer.Put("fake", "", "en", "de", []string{"tests"}, []string{"de: tests"})
+	writer.Close()
+	cache, err = xlns.ReadXlnsCache(cacheFile)
+	if err != nil {
+		t.Fatalf("ReadXlnsCache got %v", err)
+	}
+	defer cache.Close()
+	if translation, ok := cache.Get("fake", "", "en", "de", "tests"); translation != "de: tests" || !ok {
+		t.Errorf("Get got %q %v", translation, ok)
+	}
+	err = cache.Put("fake", "", "en", "fr", []string{"tests"}, []string{"fr: tests"})
+	if err == nil {
+		t.Errorf("Put to a read only cache worked")
+	}
+}
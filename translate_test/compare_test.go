@@ -0,0 +1,118 @@
+// Test comparing engines.
+package translate_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestSimilarity(t *testing.T) {
+	var tests = []struct {
+		a, b       string
+		similarity float64
+	}{
+		{"", "", 1},
+		{"Open", "open.", 1},
+		{"Hello  world", "hello, world!", 1},
+		{"abcd", "abce", 0.75},
+		{"abc", "xyz", 0},
+		{"Öffnen", "Offnen", 5.0 / 6},
+	}
+	for _, test := range tests {
+		similarity := xlns.Similarity(test.a, test.b)
+		if math.Abs(similarity-test.similarity) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) got %f expected %f", test.a, test.b, similarity, test.similarity)
+		}
+	}
+}
+
+// otherTranslator translates like fakeTranslator except for "word".
+type otherTranslator struct {
+	fakeTranslator
+}
+
+func (ot *otherTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	translated, err := ot.fakeTranslator.Translate(ctx, source, target, texts)
+	for i, text := range texts {
+		if text == "word" {
+			translated[i] = "Vokabel"
+		}
+	}
+	return translated, err
+}
+
+func (ot *otherTranslator) EngineName() (string, string) {
+	return "other", ""
+}
+
+func TestXlnsCompare(t *testing.T) {
+	dir := copyWords(t)
+	translators := []xlns.Translator{&fakeTranslator{}, &otherTranslator{}}
+	report, err := xlns.XlnsCompare(context.Background(), dir, translators, "en", "de", nil)
+	if err != nil {
+		t.Fatalf("XlnsCompare got %v", err)
+	}
+	en, _ := xlns.WordsGetWords(dir, "en")
+	if report.Compared != len(en) || report.Disagree != 1 || len(report.Lines) != 1 {
+		t.Fatalf("XlnsCompare got %+v", report)
+	}
+	line := report.Lines[0]
+	if line.Source != "word" || en[line.Line-1] != "word" ||
+		line.Translations[0] != "de: word" || line.Translations[1] != "Vokabel" {
+		t.Errorf("disagreeing line got %+v", line)
+	}
+	if report.Engines[1] != "other" {
+		t.Errorf("engines got %q", report.Engines)
+	}
+	var text, html, js bytes.Buffer
+	report.WriteText(&text)
+	if !strings.Contains(text.String(), fmt.Sprintf("1 of %d lines disagree", len(en))) ||
+		!strings.Contains(text.String(), "Vokabel\n") {
+		t.Errorf("WriteText got %s", text.String())
+	}
+	report.WriteHTML(&html)
+	if !strings.Contains(html.String(), "<td>Vokabel</td>") {
+		t.Errorf("WriteHTML got %s", html.String())
+	}
+	report.WriteJSON(&js)
+	var decoded xlns.CompareReport
+	err = json.Unmarshal(js.Bytes(), &decoded)
+	if err != nil || decoded.Lines[0].Line != line.Line {
+		t.Errorf("WriteJSON got %s %v", js.String(), err)
+	}
+	report, err = xlns.XlnsCompare(context.Background(), dir, translators, "en", "de",
+		&xlns.CompareOptions{All: true})
+	if err != nil || len(report.Lines) != len(en) || report.Lines[0].Source != "word" {
+		t.Errorf("XlnsCompare all got %+v %v", report, err)
+	}
+	// Engines not using notes ignore them.
+	report, err = xlns.XlnsCompare(context.Background(), dir, translators, "en", "de",
+		&xlns.CompareOptions{XlnsOptions: &xlns.XlnsOptions{Notes: []string{"a note"}}})
+	if err != nil || report.Disagree != 1 {
+		t.Errorf("XlnsCompare with notes got %+v %v", report, err)
+	}
+	// The budget covers every engine.
+	chars := 0
+	for _, word := range en {
+		chars += utf8.RuneCountInString(word)
+	}
+	_, err = xlns.XlnsCompare(context.Background(), dir, translators, "en", "de",
+		&xlns.CompareOptions{XlnsOptions: &xlns.XlnsOptions{Budget: chars + 1}})
+	var budgetErr *xlns.BudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Errorf("XlnsCompare over budget got %v expected a BudgetError", err)
+	}
+	_, err = xlns.XlnsCompare(context.Background(), dir, translators[:1], "en", "de", nil)
+	if err == nil {
+		t.Errorf("XlnsCompare with one engine got no error")
+	}
+}
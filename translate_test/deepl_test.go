@@ -0,0 +1,119 @@
+// Test the DeepL Translator against a local stand-in.
+package translate_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestDeepLCodes(t *testing.T) {
+	var tests = []struct {
+		lang, source, target string
+	}{
+		{"en", "EN", "EN-US"},
+		{"en-GB", "EN", "EN-GB"},
+		{"pt", "PT", "PT-PT"},
+		{"pt-BR", "PT", "PT-BR"},
+		{"es-419", "ES", "ES"},
+		{"zh-TW", "ZH", "ZH-HANT"},
+		{"de", "DE", "DE"},
+	}
+	for _, test := range tests {
+		if source := xlns.DeepLSource(test.lang); source != test.source {
+			t.Errorf("DeepLSource(%s) got %s expected %s", test.lang, source, test.source)
+		}
+		if target := xlns.DeepLTarget(test.lang); target != test.target {
+			t.Errorf("DeepLTarget(%s) got %s expected %s", test.lang, target, test.target)
+		}
+	}
+}
+
+// deeplStandIn answers translate requests like fakeTranslator, failing
+// the first with 429.
+type deeplStandIn struct {
+	mu        sync.Mutex
+	requests  int
+	formality map[string]string // By target_lang.
+}
+
+func (ds *deeplStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.requests++
+	if ds.requests == 1 {
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	if r.URL.Path != "/v2/translate" || r.Header.Get("Authorization") != "DeepL-Auth-Key key" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	r.ParseForm()
+	target := r.PostForm.Get("target_lang")
+	ds.formality[target] = r.PostForm.Get("formality")
+	type translation struct {
+		Text string `json:"text"`
+	}
+	var resp struct {
+		Translations []translation `json:"translations"`
+	}
+	texts := r.PostForm["text"]
+	if len(texts) > xlns.DEEPL_MAX_SEGMENTS {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	for _, text := range texts {
+		resp.Translations = append(resp.Translations,
+			translation{strings.ToLower(target) + ": " + text})
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestXlnsDeepL(t *testing.T) {
+	standIn := &deeplStandIn{formality: make(map[string]string)}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+	dt, err := xlns.NewDeepLTranslator("key", &xlns.DeepLOptions{
+		Endpoint:  server.URL,
+		Formality: map[string]string{"de": "less", "*": "prefer_more"},
+	})
+	if err != nil {
+		t.Fatalf("NewDeepLTranslator got %v", err)
+	}
+	dir := copyWords(t)
+	options := &xlns.XlnsOptions{Retries: 1, Backoff: time.Millisecond}
+	err = xlns.XlnsAddTranslator(dir, dt, "en", []string{"de", "pt-BR"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	en, _ := xlns.WordsGetWords(dir, "en")
+	pt, _ := xlns.WordsGetWords(dir, "pt-BR")
+	for i, word := range pt {
+		if word != "pt-br: "+en[i] {
+			t.Errorf("pt-BR line %d got %q", i+1, word)
+		}
+	}
+	if standIn.formality["DE"] != "less" || standIn.formality["PT-BR"] != "prefer_more" {
+		t.Errorf("formality got %v", standIn.formality)
+	}
+	// Only the target's own formality, if it has any, is in its model.
+	var names = []struct {
+		target, model string
+	}{
+		{"de", "formality=less"},
+		{"pt-BR", "formality=prefer_more"},
+		{"fi", ""},
+	}
+	for _, name := range names {
+		if _, model := dt.TargetEngineName(name.target); model != name.model {
+			t.Errorf("TargetEngineName(%s) got model %q expected %q", name.target, model, name.model)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Test the language detection audit.
+package translate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"unicode"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestDetectMatches(t *testing.T) {
+	var tests = []struct {
+		lang, detected string
+		matches        bool
+	}{
+		{"ja", "ja", true},
+		{"zh-TW", "zh-CN", true},
+		{"pt-BR", "PT", true},
+		{"fil", "tl", true},
+		{"he", "iw", true},
+		{"ko", "und", true},
+		{"ko", "", true},
+		{"ja", "en", false},
+		{"es-419", "pt", false},
+	}
+	for _, test := range tests {
+		if matches := xlns.DetectMatches(test.lang, test.detected); matches != test.matches {
+			t.Errorf("DetectMatches(%s, %s) got %v", test.lang, test.detected, matches)
+		}
+	}
+}
+
+// scriptDetector detects ASCII text as English and the rest as Japanese,
+// 2 texts at a time.
+type scriptDetector struct {
+	fakeTranslator
+	calls int
+}
+
+func (sd *scriptDetector) Detect(ctx context.Context, texts []string) ([]xlns.Detection, error) {
+	sd.calls++
+	detections := make([]xlns.Detection, len(texts))
+	for i, text := range texts {
+		detections[i] = xlns.Detection{Code: "ja", Confidence: 0.9}
+		ascii := true
+		for _, r := range text {
+			if r > unicode.MaxASCII {
+				ascii = false
+			}
+		}
+		if ascii {
+			detections[i] = xlns.Detection{Code: "en", Confidence: float32(len(text)) / 10}
+		}
+	}
+	return detections, nil
+}
+
+func (sd *scriptDetector) BatchLimits() xlns.BatchLimits {
+	return xlns.BatchLimits{Segments: 2}
+}
+
+func TestXlnsDetect(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]string{
+		"en":    {"Settings", "OK", "1024"},
+		"ja":    {"設定", "OK", "1024"},
+		"en-XA": {"[Šéţţîñĝš]", "[ÖĶ]", "1024"},
+	}
+	for lang, words := range files {
+		err := xlns.WordsWriteWords(dir, lang, words)
+		if err != nil {
+			t.Fatalf("WordsWriteWords got %v", err)
+		}
+	}
+	sd := &scriptDetector{}
+	found, err := xlns.XlnsDetect(context.Background(), dir, sd, nil)
+	if err != nil {
+		t.Fatalf("XlnsDetect got %v", err)
+	}
+	if len(found) != 1 || found[0].Lang != "ja" || found[0].Line != 2 ||
+		found[0].Detected != "en" || found[0].Text != "OK" {
+		t.Errorf("XlnsDetect got %v", found)
+	}
+	// en and ja in 1 batch each, without the line without letters.
+	if sd.calls != 2 {
+		t.Errorf("Detect called %d times expected 2", sd.calls)
+	}
+	found, err = xlns.XlnsDetect(context.Background(), dir, sd,
+		&xlns.DetectOptions{Langs: []string{"ja"}, Confidence: 0.5})
+	if err != nil || len(found) != 0 {
+		t.Errorf("XlnsDetect with confidence got %v %v", found, err)
+	}
+}
+
+func TestXlnsDetectBudget(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]string{
+		"en": {"Settings", "OK"}, // 10 characters.
+		"ja": {"設定", "OK"},       // 4 characters.
+	}
+	for lang, words := range files {
+		err := xlns.WordsWriteWords(dir, lang, words)
+		if err != nil {
+			t.Fatalf("WordsWriteWords got %v", err)
+		}
+	}
+	// Each language is within the budget but not both.
+	_, err := xlns.XlnsDetect(context.Background(), dir, &scriptDetector{},
+		&xlns.DetectOptions{XlnsOptions: &xlns.XlnsOptions{Budget: 12}})
+	var budgetErr *xlns.BudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Errorf("XlnsDetect over budget got %v expected a BudgetError", err)
+	}
+}
+
+// oneDetector is a scriptDetector detecting 1 text at a time, like the
+// Google V3 API.
+type oneDetector struct {
+	scriptDetector
+}
+
+func (od *oneDetector) DetectBatchLimits() xlns.BatchLimits {
+	return xlns.BatchLimits{Segments: 1}
+}
+
+func TestXlnsDetectBatchLimits(t *testing.T) {
+	dir := t.TempDir()
+	err := xlns.WordsWriteWords(dir, "ja", []string{"設定", "OK", "保存"})
+	if err != nil {
+		t.Fatalf("WordsWriteWords got %v", err)
+	}
+	// Each text is a call, and a request for the rate limits.
+	od := &oneDetector{}
+	_, err = xlns.XlnsDetect(context.Background(), dir, od, nil)
+	if err != nil {
+		t.Fatalf("XlnsDetect got %v", err)
+	}
+	if od.calls != 3 {
+		t.Errorf("Detect called %d times expected 3", od.calls)
+	}
+	router := xlns.NewPseudoRouter(od, xlns.NewPseudoTranslator(nil))
+	if limits := xlns.TranslatorDetectBatchLimits(router); limits.Segments != 1 {
+		t.Errorf("PseudoRouter detect limits got %v", limits)
+	}
+}
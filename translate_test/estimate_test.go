@@ -0,0 +1,114 @@
+// Test estimating runs.
+package translate_test
+
+import (
+	"errors"
+	"path"
+	"testing"
+	"unicode/utf8"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestXlnsEstimate(t *testing.T) {
+	dir := copyWords(t)
+	en, _ := xlns.WordsGetWords(dir, "en")
+	chars := 0
+	for _, word := range en {
+		chars += utf8.RuneCountInString(word)
+	}
+	lt := &limitedTranslator{}
+	xe, err := xlns.XlnsEstimateAdd(dir, lt, "en", []string{"de", "pl"}, nil)
+	if err != nil {
+		t.Fatalf("XlnsEstimateAdd got %v", err)
+	}
+	if xe.Segments != 2*len(en) || xe.Characters != 2*chars {
+		t.Errorf("add estimate got %d segments %d characters", xe.Segments, xe.Characters)
+	}
+	if xe.Requests != 2*((len(en)+2)/3) {
+		t.Errorf("add estimate got %d requests", xe.Requests)
+	}
+	if lt.calls != 0 || xlns.WordsHasLanguage(dir, "de") {
+		t.Errorf("estimate translated")
+	}
+	if cost := xe.Cost(20); cost != float64(2*chars)*20/1e6 {
+		t.Errorf("cost got %f", cost)
+	}
+
+	// Cached and up to date lines are not sent.
+	cache, err := xlns.OpenXlnsCache(path.Join(t.TempDir(), xlns.CACHE_FILENAME))
+	if err != nil {
+		t.Fatalf("OpenXlnsCache got %v", err)
+	}
+	defer cache.Close()
+	options := &xlns.XlnsOptions{Cache: cache}
+	err = xlns.XlnsAddTranslator(dir, lt, "en", []string{"de"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	xe, err = xlns.XlnsEstimateAdd(dir, lt, "en", []string{"de"}, options)
+	if err != nil {
+		t.Fatalf("XlnsEstimateAdd got %v", err)
+	}
+	if xe.Segments != 0 || xe.Langs[0].Cached != len(en) {
+		t.Errorf("cached estimate got %+v", xe.Langs[0])
+	}
+	xe, err = xlns.XlnsEstimateUpdate(dir, lt, "en", nil)
+	if err != nil {
+		t.Fatalf("XlnsEstimateUpdate got %v", err)
+	}
+	langs, _ := xlns.WordsLanguages(dir)
+	if len(xe.Langs) != len(langs)-2 {
+		t.Errorf("update estimate has %d languages expected %d", len(xe.Langs), len(langs)-2)
+	}
+}
+
+func TestXlnsBudget(t *testing.T) {
+	dir := copyWords(t)
+	en, _ := xlns.WordsGetWords(dir, "en")
+	chars := 0
+	for _, word := range en {
+		chars += utf8.RuneCountInString(word)
+	}
+	// Enough for one language but not two.
+	options := &xlns.XlnsOptions{Budget: chars + 1}
+	err := xlns.XlnsAddTranslator(dir, &fakeTranslator{}, "en", []string{"de", "pl"}, options)
+	var budgetErr *xlns.BudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("XlnsAddTranslator got %v expected a BudgetError", err)
+	}
+	if budgetErr.Spent != chars {
+		t.Errorf("spent %d expected %d", budgetErr.Spent, chars)
+	}
+	if len(budgetErr.Untouched) != 1 || budgetErr.Untouched[0] != "pl" {
+		t.Errorf("untouched got %v expected [pl]", budgetErr.Untouched)
+	}
+	if !xlns.WordsHasLanguage(dir, "de") || xlns.WordsHasLanguage(dir, "pl") {
+		t.Errorf("expected only de written")
+	}
+}
+
+func TestXlnsEstimateMasked(t *testing.T) {
+	dir := t.TempDir()
+	xlns.WordsWriteWords(dir, "en", []string{"Napcat Home screen", "Open %s at Home"})
+	options := &xlns.XlnsOptions{Protect: true, Glossary: readTestGlossary(t, dir)}
+	xe, err := xlns.XlnsEstimateAdd(dir, &fakeTranslator{}, "en", []string{"de"}, options)
+	if err != nil {
+		t.Fatalf("XlnsEstimateAdd got %v", err)
+	}
+	// Sent as "⟦1⟧ ⟦0⟧" and "Open ⟦0⟧ at ⟦1⟧".
+	if xe.Characters != 7+15 {
+		t.Errorf("masked estimate got %d characters expected %d", xe.Characters, 7+15)
+	}
+	// The estimate is exactly the budget needed.
+	options.Budget = xe.Characters - 1
+	err = xlns.XlnsAddTranslator(dir, &fakeTranslator{}, "en", []string{"de"}, options)
+	if err == nil {
+		t.Errorf("XlnsAddTranslator under the estimate got no error")
+	}
+	options.Budget = xe.Characters
+	err = xlns.XlnsAddTranslator(dir, &fakeTranslator{}, "en", []string{"de"}, options)
+	if err != nil {
+		t.Errorf("XlnsAddTranslator within the estimate got %v", err)
+	}
+}
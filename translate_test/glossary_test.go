@@ -0,0 +1,154 @@
+// Test glossaries.
+package translate_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+const testGlossary = `en,de,fr
+# Brand names are never translated.
+Napcat
+Home screen,Startbildschirm,Écran d'accueil
+Home,Start
+`
+
+// readTestGlossary writes testGlossary to dir and reads it back.
+func readTestGlossary(t *testing.T, dir string) *xlns.Glossary {
+	filename := path.Join(dir, "glossary.csv")
+	err := ioutil.WriteFile(filename, []byte(testGlossary), 0644)
+	if err != nil {
+		t.Fatalf("writing glossary got %v", err)
+	}
+	glossary, err := xlns.ReadGlossary(filename)
+	if err != nil {
+		t.Fatalf("ReadGlossary got %v", err)
+	}
+	return glossary
+}
+
+func TestGlossaryPairs(t *testing.T) {
+	glossary := readTestGlossary(t, t.TempDir())
+	var tests = []struct {
+		target string
+		pairs  [][2]string
+	}{
+		{"de", [][2]string{
+			{"Home screen", "Startbildschirm"},
+			{"Napcat", "Napcat"},
+			{"Home", "Start"},
+		}},
+		{"fr-CA", [][2]string{
+			{"Home screen", "Écran d'accueil"},
+			{"Napcat", "Napcat"},
+		}},
+		{"pl", [][2]string{
+			{"Napcat", "Napcat"},
+		}},
+	}
+	for _, test := range tests {
+		var pairs [][2]string
+		for _, pair := range glossary.Pairs("en", test.target) {
+			pairs = append(pairs, [2]string{pair.Source, pair.Target})
+		}
+		if !reflect.DeepEqual(pairs, test.pairs) {
+			t.Errorf("%s got %q expected %q", test.target, pairs, test.pairs)
+		}
+	}
+}
+
+// catTranslator translates like fakeTranslator but makes Napcat a cat.
+type catTranslator struct {
+	fakeTranslator
+	applies bool
+}
+
+func (ct *catTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	translated, err := ct.fakeTranslator.Translate(ctx, source, target, texts)
+	for i := range translated {
+		translated[i] = strings.ReplaceAll(translated[i], "Napcat", "Katze")
+	}
+	return translated, err
+}
+
+func (ct *catTranslator) AppliesGlossary(source, target string) bool {
+	return ct.applies
+}
+
+func TestXlnsGlossary(t *testing.T) {
+	dir := t.TempDir()
+	glossary := readTestGlossary(t, dir)
+	xlns.WordsWriteWords(dir, "en", []string{
+		"Napcat Home screen",
+		"Homes for Napcats",
+		"Go Home",
+	})
+	options := &xlns.XlnsOptions{Glossary: glossary}
+	err := xlns.XlnsAddTranslator(dir, &catTranslator{}, "en", []string{"de"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	de, _ := xlns.WordsGetWords(dir, "de")
+	expected := []string{
+		"de: Napcat Startbildschirm",
+		"de: Homes for Katzes",
+		"de: Go Start",
+	}
+	if !reflect.DeepEqual(de, expected) {
+		t.Errorf("de got %q expected %q", de, expected)
+	}
+	problems, err := xlns.WordsCheckGlossary(dir, "en", glossary)
+	if err != nil || len(problems) != 0 {
+		t.Errorf("WordsCheckGlossary got %v %v", problems, err)
+	}
+	// A translator applying the glossary itself gets the terms unmasked.
+	err = xlns.XlnsAddTranslator(dir, &catTranslator{applies: true}, "en", []string{"fr"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	problems, err = xlns.WordsCheckGlossary(dir, "en", glossary)
+	if err != nil {
+		t.Fatalf("WordsCheckGlossary got %v", err)
+	}
+	if len(problems) != 1 || problems[0].Lang != "fr" || problems[0].Line != 1 {
+		t.Errorf("WordsCheckGlossary got %v expected fr:1", problems)
+	}
+}
+
+func TestXlnsGlossaryCache(t *testing.T) {
+	dir := t.TempDir()
+	xlns.WordsWriteWords(dir, "en", []string{"Napcat rocks", "Cats rock"})
+	cache, err := xlns.OpenXlnsCache(path.Join(dir, xlns.CACHE_FILENAME))
+	if err != nil {
+		t.Fatalf("OpenXlnsCache got %v", err)
+	}
+	defer cache.Close()
+	options := &xlns.XlnsOptions{Cache: cache}
+	err = xlns.XlnsAddTranslator(dir, &catTranslator{}, "en", []string{"de"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	// A glossary added later applies to lines already cached.
+	options.Glossary = readTestGlossary(t, t.TempDir())
+	options.Full = true
+	ct := &catTranslator{}
+	err = xlns.XlnsUpdateTranslator(dir, ct, "en", options)
+	if err != nil {
+		t.Fatalf("XlnsUpdateTranslator got %v", err)
+	}
+	de, _ := xlns.WordsGetWords(dir, "de")
+	expected := []string{"de: Napcat rocks", "de: Cats rock"}
+	if !reflect.DeepEqual(de, expected) {
+		t.Errorf("de got %q expected %q", de, expected)
+	}
+	// Only the line with a glossary term is sent again.
+	if ct.texts != 1 {
+		t.Errorf("sent %d texts expected 1", ct.texts)
+	}
+}
@@ -0,0 +1,140 @@
+// Test the Google Translators against local fake servers.
+package translate_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+	trpb "google.golang.org/genproto/googleapis/cloud/translate/v3"
+	"google.golang.org/grpc"
+)
+
+// fakeGoogleServer is a V3 translation service translating like
+// fakeTranslator.
+type fakeGoogleServer struct {
+	trpb.UnimplementedTranslationServiceServer
+	parent string
+}
+
+func (fs *fakeGoogleServer) TranslateText(ctx context.Context, req *trpb.TranslateTextRequest) (*trpb.TranslateTextResponse, error) {
+	fs.parent = req.GetParent()
+	resp := &trpb.TranslateTextResponse{}
+	for _, text := range req.GetContents() {
+		resp.Translations = append(resp.Translations, &trpb.Translation{
+			TranslatedText: fmt.Sprintf("%s: %s", req.GetTargetLanguageCode(), text),
+		})
+	}
+	return resp, nil
+}
+
+func TestGoogleEndpoint(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen got %v", err)
+	}
+	server := grpc.NewServer()
+	fake := &fakeGoogleServer{}
+	trpb.RegisterTranslationServiceServer(server, fake)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	ctx := context.Background()
+	gt, err := xlns.NewGoogleTranslatorOptions(ctx, "", &xlns.GoogleOptions{
+		ProjectID: "test",
+		Location:  "us-central1",
+		Endpoint:  listener.Addr().String(),
+		Insecure:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewGoogleTranslatorOptions got %v", err)
+	}
+	defer gt.Close()
+	translated, err := gt.Translate(ctx, "en", "de", []string{"one", "two"})
+	if err != nil {
+		t.Fatalf("Translate got %v", err)
+	}
+	expected := []string{"de: one", "de: two"}
+	if !reflect.DeepEqual(translated, expected) {
+		t.Errorf("Translate got %q expected %q", translated, expected)
+	}
+	if fake.parent != "projects/test/locations/us-central1" {
+		t.Errorf("parent got %s", fake.parent)
+	}
+	// A glossary changes the model so cached translations are not reused.
+	_, plain := gt.EngineName()
+	gt.SetGlossary("terms")
+	_, model := gt.EngineName()
+	if model == plain || model != "projects/test/locations/us-central1/glossaries/terms" {
+		t.Errorf("model with glossary got %q", model)
+	}
+}
+
+func TestGoogleNamer(t *testing.T) {
+	ctx := context.Background()
+	gt, err := xlns.NewGoogleNamer(ctx, "", &xlns.GoogleOptions{
+		ProjectID: "test",
+		Location:  "us-central1",
+		MimeType:  xlns.MIME_HTML,
+	})
+	if err != nil {
+		t.Fatalf("NewGoogleNamer got %v", err)
+	}
+	defer gt.Close()
+	gt.SetGlossary("terms")
+	engine, model := gt.EngineName()
+	if engine != "google" || model != "text/html projects/test/locations/us-central1/glossaries/terms" {
+		t.Errorf("EngineName got %q %q", engine, model)
+	}
+	if gt.BatchLimits().Segments != xlns.GOOGLE_MAX_SEGMENTS {
+		t.Errorf("BatchLimits got %v", gt.BatchLimits())
+	}
+	_, err = gt.Translate(ctx, "en", "de", []string{"one"})
+	if err == nil {
+		t.Errorf("namer Translate worked")
+	}
+}
+
+func TestGoogleV2Endpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var translations []map[string]string
+		for _, text := range query["q"] {
+			translations = append(translations, map[string]string{
+				"translatedText": fmt.Sprintf("%s: %s", query.Get("target"), text),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"translations": translations},
+		})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	gt, err := xlns.NewGoogleV2Translator(ctx, "key", &xlns.GoogleOptions{
+		Endpoint: server.URL + "/",
+		Insecure: true,
+	})
+	if err != nil {
+		t.Fatalf("NewGoogleV2Translator got %v", err)
+	}
+	defer gt.Close()
+	dir := copyWords(t)
+	err = xlns.XlnsAddTranslator(dir, gt, "en", []string{"pl"}, nil)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	en, _ := xlns.WordsGetWords(dir, "en")
+	pl, _ := xlns.WordsGetWords(dir, "pl")
+	for i, word := range pl {
+		if word != "pl: "+en[i] {
+			t.Errorf("pl line %d got %q", i+1, word)
+		}
+	}
+}
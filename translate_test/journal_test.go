@@ -0,0 +1,69 @@
+// Test resuming runs from the journal.
+package translate_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+// failingTranslator fails the second batch into lang.
+type failingTranslator struct {
+	limitedTranslator
+	lang    string
+	batches int
+}
+
+func (ft *failingTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	if target == ft.lang {
+		ft.batches++
+		if ft.batches == 2 {
+			return nil, errors.New("failed")
+		}
+	}
+	return ft.limitedTranslator.Translate(ctx, source, target, texts)
+}
+
+func TestXlnsResume(t *testing.T) {
+	dir := copyWords(t)
+	ft := &failingTranslator{lang: "pl"}
+	newLangs := []string{"de", "pl", "sv"}
+	err := xlns.XlnsAddTranslator(dir, ft, "en", newLangs, nil)
+	if err == nil {
+		t.Fatalf("XlnsAdd did not fail")
+	}
+	status, err := xlns.XlnsJournalStatus(dir)
+	if err != nil || status == nil {
+		t.Fatalf("XlnsJournalStatus got %v %v", status, err)
+	}
+	if len(status.Done) != 1 || status.Done[0] != "de" {
+		t.Errorf("done got %v expected [de]", status.Done)
+	}
+	if status.Lines["pl"] != 3 {
+		t.Errorf("pl lines got %d expected 3", status.Lines["pl"])
+	}
+	// Resuming only translates what is left.
+	lt := &limitedTranslator{}
+	err = xlns.XlnsAddTranslator(dir, lt, "en", newLangs, &xlns.XlnsOptions{Resume: true})
+	if err != nil {
+		t.Fatalf("resumed XlnsAdd got %v", err)
+	}
+	en, _ := xlns.WordsGetWords(dir, "en")
+	if lt.texts != 2*len(en)-3 {
+		t.Errorf("resume translated %d texts expected %d", lt.texts, 2*len(en)-3)
+	}
+	for _, lang := range newLangs {
+		words, _ := xlns.WordsGetWords(dir, lang)
+		for i, word := range words {
+			if word != lang+": "+en[i] {
+				t.Errorf("%s line %d got %q", lang, i+1, word)
+			}
+		}
+	}
+	if _, err := os.Stat(xlns.JournalFilename(dir)); !os.IsNotExist(err) {
+		t.Errorf("journal not removed after success")
+	}
+}
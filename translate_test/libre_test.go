@@ -0,0 +1,74 @@
+// Test the LibreTranslate Translator against a local stand-in.
+package translate_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+// libreStandIn answers like a LibreTranslate server needing the API key
+// "key", translating like fakeTranslator.
+func libreStandIn(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Q      []string `json:"q"`
+		Source string   `json:"source"`
+		Target string   `json:"target"`
+		APIKey string   `json:"api_key"`
+	}
+	switch r.URL.Path {
+	case "/languages":
+		json.NewEncoder(w).Encode([]map[string]string{
+			{"code": "en", "name": "English"},
+			{"code": "es", "name": "Spanish"},
+		})
+	case "/translate":
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.APIKey != "key" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad key"})
+			return
+		}
+		var translated []string
+		for _, text := range req.Q {
+			translated = append(translated, req.Target+": "+text)
+		}
+		json.NewEncoder(w).Encode(map[string][]string{"translatedText": translated})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestXlnsLibre(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(libreStandIn))
+	defer server.Close()
+	lt, err := xlns.NewLibreTranslator(&xlns.LibreOptions{Endpoint: server.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatalf("NewLibreTranslator got %v", err)
+	}
+	langs, err := lt.Supported(context.Background(), "en")
+	if err != nil || len(langs) != 2 || langs[1].Code != "es" || langs[1].Name != "Spanish" {
+		t.Errorf("Supported got %v %v", langs, err)
+	}
+	dir := copyWords(t)
+	err = xlns.XlnsAddTranslator(dir, lt, "en", []string{"es-419"}, nil)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	en, _ := xlns.WordsGetWords(dir, "en")
+	es, _ := xlns.WordsGetWords(dir, "es-419")
+	for i, word := range es {
+		if word != "es: "+en[i] {
+			t.Errorf("es-419 line %d got %q", i+1, word)
+		}
+	}
+	lt, _ = xlns.NewLibreTranslator(&xlns.LibreOptions{Endpoint: server.URL})
+	_, err = lt.Translate(context.Background(), "en", "es", []string{"Hello"})
+	if err == nil || xlns.IsRetryable(err) {
+		t.Errorf("Translate without a key got %v", err)
+	}
+}
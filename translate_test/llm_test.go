@@ -0,0 +1,132 @@
+// Test the LLM Translator against a local chat completions stand-in.
+package translate_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+// llmStandIn answers chat completions like fakeTranslator, adding the
+// note, in reverse order inside a code fence.  The first answer leaves a
+// translation out.
+type llmStandIn struct {
+	mu       sync.Mutex
+	requests int
+	glossary int // Glossary terms sent.
+}
+
+func (ls *llmStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.requests++
+	if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer key" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]string{"message": "bad key"}})
+		return
+	}
+	var chat struct {
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	json.NewDecoder(r.Body).Decode(&chat)
+	var req struct {
+		Target   string              `json:"target"`
+		Glossary []map[string]string `json:"glossary"`
+		Texts    []struct {
+			ID   int    `json:"id"`
+			Text string `json:"text"`
+			Note string `json:"note"`
+		} `json:"texts"`
+	}
+	json.Unmarshal([]byte(chat.Messages[1].Content), &req)
+	ls.glossary += len(req.Glossary)
+	target := strings.Fields(req.Target)[0]
+	var translations []map[string]interface{}
+	for i := len(req.Texts) - 1; i >= 0; i-- {
+		text := req.Texts[i]
+		translated := target + ": " + text.Text
+		if text.Note != "" {
+			translated += " (" + text.Note + ")"
+		}
+		translations = append(translations, map[string]interface{}{
+			"id": text.ID, "text": translated})
+	}
+	if ls.requests == 1 {
+		translations = translations[1:]
+	}
+	content, _ := json.Marshal(map[string]interface{}{"translations": translations})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"choices": []map[string]interface{}{{
+			"message":       map[string]string{"role": "assistant", "content": "```json\n" + string(content) + "\n```"},
+			"finish_reason": "stop",
+		}},
+	})
+}
+
+func TestXlnsLLM(t *testing.T) {
+	standIn := &llmStandIn{}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+	dir := copyWords(t)
+	en, _ := xlns.WordsGetWords(dir, "en")
+	err := ioutil.WriteFile(path.Join(dir, "en"+xlns.NOTES_SUFFIX), []byte("\na note\n"), 0644)
+	if err != nil {
+		t.Fatalf("writing notes got %v", err)
+	}
+	notes, err := xlns.WordsGetNotes(dir, "en")
+	if err != nil || len(notes) != len(en) || notes[1] != "a note" {
+		t.Fatalf("WordsGetNotes got %q %v", notes, err)
+	}
+	glossary := &xlns.Glossary{
+		Langs: []string{"en", "de"},
+		Rows:  [][]string{{strings.Fields(en[0])[0], "Wort"}},
+	}
+	lt, err := xlns.NewLLMTranslator(&xlns.LLMOptions{
+		Endpoint: server.URL + "/v1",
+		APIKey:   "key",
+		Glossary: glossary,
+	})
+	if err != nil {
+		t.Fatalf("NewLLMTranslator got %v", err)
+	}
+	options := &xlns.XlnsOptions{
+		Retries:  1,
+		Backoff:  time.Millisecond,
+		Notes:    notes,
+		Glossary: glossary,
+	}
+	err = xlns.XlnsAddTranslator(dir, lt, "en", []string{"de"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	de, _ := xlns.WordsGetWords(dir, "de")
+	for i, word := range de {
+		expected := "de: " + en[i]
+		if notes[i] != "" {
+			expected += fmt.Sprintf(" (%s)", notes[i])
+		}
+		if word != expected {
+			t.Errorf("de line %d got %q expected %q", i+1, word, expected)
+		}
+	}
+	if standIn.glossary == 0 {
+		t.Errorf("got no glossary terms sent")
+	}
+	options.Notes = notes[1:]
+	err = xlns.XlnsAddTranslator(dir, lt, "en", []string{"fr-CA"}, options)
+	if err == nil {
+		t.Errorf("XlnsAdd with too few notes got no error")
+	}
+}
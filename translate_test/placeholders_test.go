@@ -0,0 +1,110 @@
+// Test protecting placeholders.
+package translate_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestPlaceholders(t *testing.T) {
+	var tests = []struct {
+		text         string
+		placeholders []string
+	}{
+		{"Hello %s, you have %d messages", []string{"%s", "%d"}},
+		{"%1$s of %2$s", []string{"%1$s", "%2$s"}},
+		{"Welcome {name} to $(APP_NAME)", []string{"{name}", "$(APP_NAME)"}},
+		{"Press <b>Save</b>&nbsp;now", []string{"<b>", "</b>", "&nbsp;"}},
+		{"100% sure", nil},
+		{"Plain", nil},
+	}
+	for _, test := range tests {
+		placeholders := xlns.Placeholders(test.text)
+		if !reflect.DeepEqual(placeholders, test.placeholders) {
+			t.Errorf("%q got %q expected %q", test.text, placeholders, test.placeholders)
+		}
+		masked, saved := xlns.MaskPlaceholders(test.text)
+		if len(test.placeholders) != 0 && strings.Contains(masked, test.placeholders[0]) {
+			t.Errorf("%q masked as %q", test.text, masked)
+		}
+		unmasked := xlns.UnmaskPlaceholders(masked, saved)
+		if unmasked != test.text {
+			t.Errorf("%q unmasked as %q", test.text, unmasked)
+		}
+	}
+}
+
+func TestPlaceholdersMatch(t *testing.T) {
+	var tests = []struct {
+		source, translation string
+		match               bool
+	}{
+		{"Hello %s", "Hallo %s", true},
+		{"%s of %d", "%d von %s", true},
+		{"Hello %s", "Hallo", false},
+		{"Hello {name}", "Hallo {Name}", false},
+		{"Hello {name}", "Hallo ⟦0⟧", false},
+	}
+	for _, test := range tests {
+		match := xlns.PlaceholdersMatch(test.source, test.translation)
+		if match != test.match {
+			t.Errorf("%q %q got %v expected %v",
+				test.source, test.translation, match, test.match)
+		}
+	}
+}
+
+// mangleTranslator translates placeholders it sees and drops masked
+// tokens from the last text.
+type mangleTranslator struct {
+	fakeTranslator
+}
+
+func (mt *mangleTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	translated, err := mt.fakeTranslator.Translate(ctx, source, target, texts)
+	for i := range translated {
+		translated[i] = strings.ReplaceAll(translated[i], "{name}", "{nom}")
+	}
+	last := len(translated) - 1
+	translated[last] = strings.Split(translated[last], "⟦")[0]
+	return translated, err
+}
+
+func TestXlnsProtect(t *testing.T) {
+	dir := t.TempDir()
+	xlns.WordsWriteWords(dir, "en", []string{"Hello {name}", "Open %s now"})
+	var problems []xlns.WordsProblem
+	options := &xlns.XlnsOptions{
+		Protect: true,
+		Warn: func(problem xlns.WordsProblem) {
+			problems = append(problems, problem)
+		},
+	}
+	err := xlns.XlnsAddTranslator(dir, &mangleTranslator{}, "en", []string{"fr"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	fr, _ := xlns.WordsGetWords(dir, "fr")
+	if fr[0] != "fr: Hello {name}" {
+		t.Errorf("protected placeholder got %q", fr[0])
+	}
+	if len(problems) != 1 || problems[0].Line != 2 {
+		t.Errorf("problems got %v expected line 2", problems)
+	}
+	found, err := xlns.WordsCheckPlaceholders(dir, "en")
+	if err != nil {
+		t.Fatalf("WordsCheckPlaceholders got %v", err)
+	}
+	if len(found) != 1 || found[0].Lang != "fr" || found[0].Line != 2 {
+		t.Errorf("WordsCheckPlaceholders got %v", found)
+	}
+	options.StrictPlaceholders = true
+	err = xlns.XlnsAddTranslator(dir, &mangleTranslator{}, "en", []string{"de"}, options)
+	if err == nil {
+		t.Errorf("strict XlnsAdd got no error")
+	}
+}
@@ -0,0 +1,128 @@
+// Test the plugin Translator running this test binary as the plugin.
+package translate_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+const PLUGIN_ENV = "TRANSLATE_TEST_PLUGIN"
+
+// TestPluginProcess is the plugin when run by TestXlnsPlugin.  It
+// translates like fakeTranslator, in batches of at most 3, failing the
+// first translate request with a retryable error.  Run as "silent" it
+// never answers info.
+func TestPluginProcess(t *testing.T) {
+	mode := os.Getenv(PLUGIN_ENV)
+	if mode == "" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+	translates := 0
+	for scanner.Scan() {
+		var req struct {
+			ID     int      `json:"id"`
+			Method string   `json:"method"`
+			Target string   `json:"target"`
+			Texts  []string `json:"texts"`
+		}
+		json.Unmarshal(scanner.Bytes(), &req)
+		resp := map[string]interface{}{"id": req.ID}
+		switch req.Method {
+		case "info":
+			if mode == "silent" {
+				continue
+			}
+			resp["engine"] = "fake"
+			resp["model"] = "1"
+			resp["limits"] = map[string]int{"segments": 3}
+		case "translate":
+			translates++
+			if translates == 1 {
+				resp["error"] = "busy"
+				resp["retryable"] = true
+				break
+			}
+			if len(req.Texts) > 3 {
+				resp["error"] = "too many texts"
+				break
+			}
+			var translated []string
+			for _, text := range req.Texts {
+				translated = append(translated, req.Target+": "+text)
+			}
+			resp["translations"] = translated
+		case "supported":
+			resp["languages"] = []map[string]string{{"code": "de", "name": "German"}}
+		default:
+			resp["error"] = "unknown method " + req.Method
+		}
+		encoder.Encode(resp)
+	}
+	os.Exit(0)
+}
+
+func TestXlnsPlugin(t *testing.T) {
+	t.Setenv(PLUGIN_ENV, "1")
+	pt, err := xlns.NewPluginTranslator(context.Background(), os.Args[0], "-test.run=^TestPluginProcess$")
+	if err != nil {
+		t.Fatalf("NewPluginTranslator got %v", err)
+	}
+	engine, model := pt.EngineName()
+	if engine != "fake" || model != "1" || pt.BatchLimits().Segments != 3 {
+		t.Errorf("info got %s %s %v", engine, model, pt.BatchLimits())
+	}
+	langs, err := pt.Supported(context.Background(), "en")
+	if err != nil || len(langs) != 1 || langs[0].Name != "German" {
+		t.Errorf("Supported got %v %v", langs, err)
+	}
+	_, err = pt.Detect(context.Background(), []string{"Hallo"})
+	if err == nil || xlns.IsRetryable(err) {
+		t.Errorf("Detect got %v", err)
+	}
+	dir := copyWords(t)
+	options := &xlns.XlnsOptions{Retries: 1, Backoff: time.Millisecond}
+	err = xlns.XlnsAddTranslator(dir, pt, "en", []string{"de"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	en, _ := xlns.WordsGetWords(dir, "en")
+	de, _ := xlns.WordsGetWords(dir, "de")
+	for i, word := range de {
+		if word != "de: "+en[i] {
+			t.Errorf("de line %d got %q", i+1, word)
+		}
+	}
+	err = pt.Close()
+	if err != nil {
+		t.Errorf("Close got %v", err)
+	}
+}
+
+func TestXlnsPluginSilent(t *testing.T) {
+	t.Setenv(PLUGIN_ENV, "silent")
+	start := time.Now()
+	pt, err := xlns.NewPluginTranslator(context.Background(), os.Args[0], "-test.run=^TestPluginProcess$")
+	if err != nil {
+		t.Fatalf("NewPluginTranslator got %v", err)
+	}
+	defer pt.Close()
+	if time.Since(start) > 2*xlns.PLUGIN_INFO_TIMEOUT {
+		t.Errorf("NewPluginTranslator took %v", time.Since(start))
+	}
+	engine, _ := pt.EngineName()
+	if engine != os.Args[0] || pt.BatchLimits().Segments != 0 {
+		t.Errorf("info got %s %v", engine, pt.BatchLimits())
+	}
+	langs, err := pt.Supported(context.Background(), "en")
+	if err != nil || len(langs) != 1 {
+		t.Errorf("Supported got %v %v", langs, err)
+	}
+}
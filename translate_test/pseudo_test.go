@@ -0,0 +1,104 @@
+// Test pseudo-localization.
+package translate_test
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestPseudoTranslator(t *testing.T) {
+	var tests = []struct {
+		options *xlns.PseudoOptions
+		target  string
+		text    string
+		pseudo  string
+	}{
+		{nil, "en-XA", "Hello", "[Ĥéļļö o]"},
+		{&xlns.PseudoOptions{Expansion: -1, NoMarkers: true}, "en-XA", "Hi %s, <b>ok</b>", "Ĥî %s, <b>öķ</b>"},
+		{&xlns.PseudoOptions{Expansion: 1}, "fr-xa", "Go", "[Ĝö o]"},
+		{&xlns.PseudoOptions{NoMarkers: true}, "ar-XB", "Hi {name}!", "\u200f\u202eHi\u202c\u200f {name}!"},
+	}
+	for _, test := range tests {
+		pt := xlns.NewPseudoTranslator(test.options)
+		pseudo, err := pt.Translate(context.Background(), "en", test.target, []string{test.text})
+		if err != nil {
+			t.Fatalf("Translate got %v", err)
+		}
+		if pseudo[0] != test.pseudo {
+			t.Errorf("%s %q got %q expected %q", test.target, test.text, pseudo[0], test.pseudo)
+		}
+	}
+	_, err := xlns.NewPseudoTranslator(nil).Translate(context.Background(), "en", "de", []string{"Hi"})
+	if err == nil {
+		t.Errorf("Translate into de got no error")
+	}
+}
+
+func TestXlnsPseudo(t *testing.T) {
+	dir := copyWords(t)
+	ft := &fakeTranslator{}
+	pr := &xlns.PseudoRouter{Translator: ft, Pseudo: xlns.NewPseudoTranslator(nil)}
+	err := xlns.XlnsAddTranslator(dir, pr, "en", []string{"en-XA", "de"}, nil)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	en, _ := xlns.WordsGetWords(dir, "en")
+	if ft.texts != len(en) {
+		t.Errorf("fakeTranslator got %d texts expected %d", ft.texts, len(en))
+	}
+	xa, _ := xlns.WordsGetWords(dir, "en-XA")
+	expected, _ := xlns.NewPseudoTranslator(nil).Translate(context.Background(), "en", "en-XA", en)
+	if !reflect.DeepEqual(xa, expected) {
+		t.Errorf("en-XA got %q expected %q", xa, expected)
+	}
+}
+
+func TestXlnsPseudoCache(t *testing.T) {
+	dir := copyWords(t)
+	cache, err := xlns.OpenXlnsCache(path.Join(t.TempDir(), xlns.CACHE_FILENAME))
+	if err != nil {
+		t.Fatalf("OpenXlnsCache got %v", err)
+	}
+	defer cache.Close()
+	options := &xlns.XlnsOptions{Cache: cache}
+	pr := &xlns.PseudoRouter{Translator: &fakeTranslator{}, Pseudo: xlns.NewPseudoTranslator(nil)}
+	err = xlns.XlnsAddTranslator(dir, pr, "en", []string{"en-XA"}, options)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	// Other pseudo options are not answered from the cache.
+	pseudo := xlns.NewPseudoTranslator(&xlns.PseudoOptions{Expansion: -1, NoMarkers: true})
+	pr.Pseudo = pseudo
+	err = xlns.XlnsUpdateTranslator(dir, pr, "en", &xlns.XlnsOptions{Cache: cache, Full: true})
+	if err != nil {
+		t.Fatalf("XlnsUpdateTranslator got %v", err)
+	}
+	en, _ := xlns.WordsGetWords(dir, "en")
+	xa, _ := xlns.WordsGetWords(dir, "en-XA")
+	expected, _ := pseudo.Translate(context.Background(), "en", "en-XA", en)
+	if !reflect.DeepEqual(xa, expected) {
+		t.Errorf("en-XA got %q expected %q", xa, expected)
+	}
+	engine, _ := xlns.TranslatorTargetEngine(pr, "en-XA")
+	if engine != "pseudo" {
+		t.Errorf("en-XA engine got %s expected pseudo", engine)
+	}
+}
+
+func TestNewPseudoRouter(t *testing.T) {
+	pseudo := xlns.NewPseudoTranslator(nil)
+	if _, ok := xlns.NewPseudoRouter(&fakeTranslator{}, pseudo).(xlns.NotesTranslator); ok {
+		t.Errorf("router for fakeTranslator is a NotesTranslator")
+	}
+	lt, err := xlns.NewLLMTranslator(&xlns.LLMOptions{APIKey: "key"})
+	if err != nil {
+		t.Fatalf("NewLLMTranslator got %v", err)
+	}
+	if _, ok := xlns.NewPseudoRouter(lt, pseudo).(xlns.NotesTranslator); !ok {
+		t.Errorf("router for LLMTranslator is not a NotesTranslator")
+	}
+}
@@ -0,0 +1,49 @@
+// Test recording and replaying translations.
+package translate_test
+
+import (
+	"path"
+	"reflect"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestRecordReplay(t *testing.T) {
+	cassette := path.Join(t.TempDir(), "cassette.json")
+	recorded := copyWords(t)
+	rt := xlns.NewRecordTranslator(&limitedTranslator{}, cassette)
+	err := xlns.XlnsAddTranslator(recorded, rt, "en", []string{"de", "pl"}, nil)
+	if err != nil {
+		t.Fatalf("recording XlnsAdd got %v", err)
+	}
+	err = rt.Close()
+	if err != nil {
+		t.Fatalf("Close got %v", err)
+	}
+
+	replayed := copyWords(t)
+	pt, err := xlns.NewReplayTranslator(cassette)
+	if err != nil {
+		t.Fatalf("NewReplayTranslator got %v", err)
+	}
+	defer pt.Close()
+	if limits := xlns.TranslatorBatchLimits(pt); limits.Segments != 3 {
+		t.Errorf("replayed limits got %v", limits)
+	}
+	err = xlns.XlnsAddTranslator(replayed, pt, "en", []string{"de", "pl"}, nil)
+	if err != nil {
+		t.Fatalf("replaying XlnsAdd got %v", err)
+	}
+	for _, lang := range []string{"de", "pl"} {
+		want, _ := xlns.WordsGetWords(recorded, lang)
+		got, _ := xlns.WordsGetWords(replayed, lang)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("replayed %s got %q expected %q", lang, got, want)
+		}
+	}
+	err = xlns.XlnsAddTranslator(replayed, pt, "en", []string{"sv"}, nil)
+	if err == nil {
+		t.Errorf("replaying an unrecorded request got no error")
+	}
+}
@@ -0,0 +1,67 @@
+// Test the script checks.
+package translate_test
+
+import (
+	"strings"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestScriptProblem(t *testing.T) {
+	var tests = []struct {
+		lang, source, translation string
+		problem                   string // Start of the problem, "" for none.
+	}{
+		{"zh-CN", "Settings", "设置", ""},
+		{"zh-CN", "Settings", "Settings", "mostly Latin letters, expected Han"},
+		{"zh-TW", "Open the file", "開啟這個檔案", ""},
+		{"zh-TW", "Open the file", "打开这个文件", "3 Simplified Chinese characters"},
+		{"zh-Hant-HK", "Back", "返回這裡", ""},
+		{"zh-CN", "Settings", "設定這個", "3 Traditional Chinese characters"},
+		{"ja", "Search with Google", "Googleで検索", ""},
+		{"ja", "Data", "データ", ""},
+		{"ja", "Open %s", "%s を開く", ""},
+		{"ja", "Open", "Open", "mostly Latin letters"},
+		{"ko", "Settings", "설정", ""},
+		{"ko", "Settings", "設定", ""},
+		{"ko", "Settings", "настройки", "mostly Cyrillic letters, expected Hangul or Han"},
+		{"ru", "Save", "Сохранить", ""},
+		{"ru", "Save", "Save", "mostly Latin letters, expected Cyrillic"},
+		{"sr", "Save", "Sačuvaj", ""},
+		{"de", "Save", "Speichern", ""},
+		{"de", "Save", "保存", "mostly Han letters, expected Latin"},
+		{"ar-XB", "Save", "evaS", ""},
+		{"xx", "Save", "保存", ""},
+	}
+	for _, test := range tests {
+		problem := xlns.ScriptProblem(test.lang, test.source, test.translation)
+		if test.problem == "" && problem != "" ||
+			!strings.HasPrefix(problem, test.problem) {
+			t.Errorf("ScriptProblem(%s, %q, %q) got %q expected %q",
+				test.lang, test.source, test.translation, problem, test.problem)
+		}
+	}
+}
+
+func TestWordsCheckScripts(t *testing.T) {
+	problems, err := xlns.WordsCheckScripts(wordsDir, "en")
+	if err != nil {
+		t.Fatalf("WordsCheckScripts got %v", err)
+	}
+	for _, problem := range problems {
+		t.Errorf("%s", problem)
+	}
+	dir := copyWords(t)
+	en, _ := xlns.WordsGetWords(dir, "en")
+	ja, _ := xlns.WordsGetWords(dir, "ja")
+	ja[2] = en[2]
+	err = xlns.WordsWriteWords(dir, "ja", ja)
+	if err != nil {
+		t.Fatalf("WordsWriteWords got %v", err)
+	}
+	problems, err = xlns.WordsCheckScripts(dir, "en")
+	if err != nil || len(problems) != 1 || problems[0].Lang != "ja" || problems[0].Line != 3 {
+		t.Errorf("WordsCheckScripts got %v %v", problems, err)
+	}
+}
@@ -0,0 +1,114 @@
+// Test the Translator helpers.
+package translate_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestBatches(t *testing.T) {
+	var tests = []struct {
+		limits xlns.BatchLimits
+		texts  []string
+		ends   []int
+	}{
+		{xlns.BatchLimits{}, []string{"a", "b", "c"}, []int{3}},
+		{xlns.BatchLimits{Segments: 2}, []string{"a", "b", "c"}, []int{2, 3}},
+		{xlns.BatchLimits{CodePoints: 4}, []string{"ab", "cd", "e"}, []int{2, 3}},
+		{xlns.BatchLimits{CodePoints: 4}, []string{"äö", "üß", "e"}, []int{2, 3}},
+		{xlns.BatchLimits{CodePoints: 2}, []string{"a", "long", "b"}, []int{1, 2, 3}},
+		{xlns.BatchLimits{Bytes: 20}, []string{"abc", "def", "g"}, []int{2, 3}},
+		{xlns.BatchLimits{Bytes: 20}, []string{"äö", "üß"}, []int{1, 2}},
+		{xlns.BatchLimits{Bytes: 19}, []string{"a b&c", "d"}, []int{1, 2}},
+		{xlns.BatchLimits{Segments: 2}, nil, nil},
+	}
+	for _, test := range tests {
+		ends := test.limits.Batches(test.texts)
+		if !reflect.DeepEqual(ends, test.ends) {
+			t.Errorf("%+v batches of %q got %v expected %v",
+				test.limits, test.texts, ends, test.ends)
+		}
+	}
+}
+
+// limitedTranslator is a fakeTranslator with small batch limits.
+type limitedTranslator struct {
+	fakeTranslator
+	drop bool // Drop the last translation of each batch.
+}
+
+func (lt *limitedTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	if len(texts) > 3 {
+		return nil, os.ErrInvalid
+	}
+	translated, err := lt.fakeTranslator.Translate(ctx, source, target, texts)
+	if lt.drop {
+		translated = translated[:len(translated)-1]
+	}
+	return translated, err
+}
+
+func (lt *limitedTranslator) BatchLimits() xlns.BatchLimits {
+	return xlns.BatchLimits{Segments: 3}
+}
+
+func TestXlnsBatches(t *testing.T) {
+	dir := copyWords(t)
+	lt := &limitedTranslator{}
+	err := xlns.XlnsAddTranslator(dir, lt, "en", []string{"de"}, nil)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	en, _ := xlns.WordsGetWords(dir, "en")
+	de, _ := xlns.WordsGetWords(dir, "de")
+	if lt.calls != (len(en)+2)/3 {
+		t.Errorf("made %d calls for %d lines", lt.calls, len(en))
+	}
+	for i, word := range de {
+		if word != "de: "+en[i] {
+			t.Errorf("line %d got %q", i+1, word)
+		}
+	}
+	// A short result must not be written.
+	lt = &limitedTranslator{drop: true}
+	err = xlns.XlnsAddTranslator(dir, lt, "en", []string{"pl"}, nil)
+	if err == nil {
+		t.Errorf("XlnsAdd with missing translations got no error")
+	}
+	if xlns.WordsHasLanguage(dir, "pl") {
+		t.Errorf("XlnsAdd with missing translations wrote pl")
+	}
+}
+
+func TestGoogleOptions(t *testing.T) {
+	options := &xlns.GoogleOptions{MimeType: "text/markdown"}
+	_, err := xlns.NewGoogleTranslatorOptions(context.Background(), "credentials.json", options)
+	if err == nil {
+		t.Errorf("NewGoogleTranslatorOptions allowed %s", options.MimeType)
+	}
+}
+
+func TestGoogleV2Options(t *testing.T) {
+	ctx := context.Background()
+	_, err := xlns.NewGoogleV2Translator(ctx, "key", &xlns.GoogleOptions{MimeType: "text/markdown"})
+	if err == nil {
+		t.Errorf("NewGoogleV2Translator allowed text/markdown")
+	}
+	gt, err := xlns.NewGoogleV2Translator(ctx, "key", &xlns.GoogleOptions{MimeType: xlns.MIME_HTML})
+	if err != nil {
+		t.Fatalf("NewGoogleV2Translator got %v", err)
+	}
+	defer gt.Close()
+	engine, model := xlns.TranslatorEngine(gt)
+	if engine != "google-v2" || model != xlns.MIME_HTML {
+		t.Errorf("TranslatorEngine got %s %s", engine, model)
+	}
+	_, err = gt.Translate(ctx, "not a language", "de", []string{"Hello"})
+	if err == nil {
+		t.Errorf("Translate allowed a bad source language")
+	}
+}
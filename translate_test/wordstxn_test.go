@@ -0,0 +1,117 @@
+// Test staged changes and locking of words directories.
+package translate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+func TestWordsLock(t *testing.T) {
+	dir := copyWords(t)
+	unlock, err := xlns.WordsLock(dir)
+	if err != nil {
+		t.Fatalf("WordsLock got %v", err)
+	}
+	_, err = xlns.WordsLock(dir)
+	if err == nil {
+		t.Errorf("second WordsLock got no error")
+	}
+	err = xlns.XlnsAddTranslator(dir, &fakeTranslator{}, "en", []string{"de"}, nil)
+	if err == nil {
+		t.Errorf("XlnsAdd of locked directory got no error")
+	}
+	unlock()
+	err = xlns.XlnsAddTranslator(dir, &fakeTranslator{}, "en", []string{"de"}, nil)
+	if err != nil {
+		t.Errorf("XlnsAdd after unlock got %v", err)
+	}
+}
+
+func TestWordsTxn(t *testing.T) {
+	dir := copyWords(t)
+	txn, err := xlns.WordsBegin(dir)
+	if err != nil {
+		t.Fatalf("WordsBegin got %v", err)
+	}
+	txn.WriteWords("de", []string{"eins"})
+	if xlns.WordsHasLanguage(dir, "de") {
+		t.Errorf("de visible before commit")
+	}
+	err = txn.Commit()
+	if err != nil {
+		t.Fatalf("Commit got %v", err)
+	}
+	words, _ := xlns.WordsGetWords(dir, "de")
+	if len(words) != 1 || words[0] != "eins" {
+		t.Errorf("committed de got %v", words)
+	}
+	txn, _ = xlns.WordsBegin(dir)
+	txn.WriteWords("pl", []string{"jeden"})
+	txn.Abort()
+	if xlns.WordsHasLanguage(dir, "pl") {
+		t.Errorf("pl written after abort")
+	}
+	assertNoStaging(t, dir)
+}
+
+func TestWordsTxnRollback(t *testing.T) {
+	dir := copyWords(t)
+	fi, _ := xlns.WordsGetWords(dir, "fi")
+	txn, err := xlns.WordsBegin(dir)
+	if err != nil {
+		t.Fatalf("WordsBegin got %v", err)
+	}
+	txn.WriteWords("de", []string{"eins"})
+	txn.WriteWords("fi", []string{"yksi"})
+	txn.WriteWords("pl", []string{"jeden"})
+	// Losing the last staged file fails the commit part way.
+	staged, _ := filepath.Glob(path.Join(dir, xlns.STAGE_PREFIX+"*", "pl"+xlns.WORDS_SUFFIX))
+	if len(staged) != 1 {
+		t.Fatalf("staged pl got %v", staged)
+	}
+	os.Remove(staged[0])
+	err = txn.Commit()
+	if err == nil {
+		t.Fatalf("Commit without pl got no error")
+	}
+	if xlns.WordsHasLanguage(dir, "de") {
+		t.Errorf("de left after failed commit")
+	}
+	words, _ := xlns.WordsGetWords(dir, "fi")
+	if !reflect.DeepEqual(words, fi) {
+		t.Errorf("fi after failed commit got %v", words)
+	}
+	assertNoStaging(t, dir)
+}
+
+func TestXlnsAllOrNothing(t *testing.T) {
+	dir := copyWords(t)
+	ft := &failingTranslator{lang: "sv"}
+	options := &xlns.XlnsOptions{AllOrNothing: true}
+	err := xlns.XlnsAddTranslator(dir, ft, "en", []string{"de", "pl", "sv"}, options)
+	if err == nil {
+		t.Fatalf("XlnsAdd did not fail")
+	}
+	for _, lang := range []string{"de", "pl", "sv"} {
+		if xlns.WordsHasLanguage(dir, lang) {
+			t.Errorf("%s written by failed all or nothing add", lang)
+		}
+	}
+	assertNoStaging(t, dir)
+}
+
+func assertNoStaging(t *testing.T, dir string) {
+	fis, _ := ioutil.ReadDir(dir)
+	for _, fi := range fis {
+		if strings.HasPrefix(fi.Name(), xlns.STAGE_PREFIX) {
+			t.Errorf("staging %s left behind", fi.Name())
+		}
+	}
+}
@@ -0,0 +1,280 @@
+// Test the Xlns functions using a fake Translator.
+package translate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	xlns "github.com/napcatstudio/translate/v2"
+)
+
+// fakeTranslator "translates" by prefixing the target language.
+type fakeTranslator struct {
+	calls int // Number of Translate calls.
+	texts int // Number of texts translated.
+}
+
+func (ft *fakeTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	ft.calls++
+	ft.texts += len(texts)
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = fmt.Sprintf("%s: %s", target, text)
+	}
+	return translated, nil
+}
+
+func (ft *fakeTranslator) Supported(ctx context.Context, displayLang string) ([]xlns.Language, error) {
+	return []xlns.Language{{Code: "en", Name: "English"}}, nil
+}
+
+func (ft *fakeTranslator) Detect(ctx context.Context, texts []string) ([]xlns.Detection, error) {
+	detections := make([]xlns.Detection, len(texts))
+	for i := range texts {
+		detections[i] = xlns.Detection{Code: "en", Confidence: 1}
+	}
+	return detections, nil
+}
+
+func (ft *fakeTranslator) Close() error {
+	return nil
+}
+
+// copyWords copies the test words directory to a temporary directory.
+func copyWords(t *testing.T) string {
+	dir := t.TempDir()
+	fis, err := ioutil.ReadDir(wordsDir)
+	if err != nil {
+		t.Fatalf("%s directory problem (%v)", wordsDir, err)
+	}
+	for _, fi := range fis {
+		data, err := ioutil.ReadFile(path.Join(wordsDir, fi.Name()))
+		if err != nil {
+			t.Fatalf("reading %s got %v", fi.Name(), err)
+		}
+		err = ioutil.WriteFile(path.Join(dir, fi.Name()), data, 0644)
+		if err != nil {
+			t.Fatalf("writing %s got %v", fi.Name(), err)
+		}
+	}
+	return dir
+}
+
+func TestXlnsAdd(t *testing.T) {
+	dir := copyWords(t)
+	ft := &fakeTranslator{}
+	err := xlns.XlnsAddTranslator(dir, ft, "en", []string{"de", "pl"}, nil)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	enWords, _ := xlns.WordsGetWords(dir, "en")
+	for _, lang := range []string{"de", "pl"} {
+		words, err := xlns.WordsGetWords(dir, lang)
+		if err != nil {
+			t.Fatalf("%s WordsGetWords got %v", lang, err)
+		}
+		if len(words) != len(enWords) {
+			t.Fatalf("%s has %d words expected %d", lang, len(words), len(enWords))
+		}
+		for i, word := range words {
+			expected := fmt.Sprintf("%s: %s", lang, enWords[i])
+			if word != expected {
+				t.Errorf("%s line %d got %q expected %q", lang, i+1, word, expected)
+			}
+		}
+	}
+	if err := xlns.WordsCheck(dir); err != nil {
+		t.Errorf("WordsCheck got %v", err)
+	}
+}
+
+func TestXlnsCredentials(t *testing.T) {
+	dir := copyWords(t)
+	missing := path.Join(dir, "missing.json")
+	err := xlns.XlnsAdd(dir, missing, "en", []string{"de"})
+	if err == nil {
+		t.Errorf("XlnsAdd with missing credentials got no error")
+	}
+	if _, err := xlns.WordsGetWords(dir, "de"); err == nil {
+		t.Errorf("XlnsAdd with missing credentials wrote de")
+	}
+	err = xlns.XlnsUpdate(dir, missing, "en")
+	if err == nil {
+		t.Errorf("XlnsUpdate with missing credentials got no error")
+	}
+	err = xlns.XlnsSupported(missing, "en")
+	if err == nil {
+		t.Errorf("XlnsSupported with missing credentials got no error")
+	}
+}
+
+func TestXlnsUpdate(t *testing.T) {
+	dir := copyWords(t)
+	ft := &fakeTranslator{}
+	err := xlns.XlnsUpdateTranslator(dir, ft, "en", nil)
+	if err != nil {
+		t.Fatalf("XlnsUpdateTranslator got %v", err)
+	}
+	words, _ := xlns.WordsGetWords(dir, "fi")
+	if words[1] != "fi: tests" {
+		t.Errorf("fi not updated got %q", words[1])
+	}
+	if err := xlns.WordsCheck(dir); err != nil {
+		t.Errorf("WordsCheck got %v", err)
+	}
+}
+
+func TestXlnsUpdateIncremental(t *testing.T) {
+	dir := copyWords(t)
+	ft := &fakeTranslator{}
+	err := xlns.XlnsAddTranslator(dir, ft, "en", []string{"de"}, nil)
+	if err != nil {
+		t.Fatalf("XlnsAddTranslator got %v", err)
+	}
+	// The other languages have no fingerprints so are fully translated.
+	err = xlns.XlnsUpdateTranslator(dir, ft, "en", nil)
+	if err != nil {
+		t.Fatalf("XlnsUpdateTranslator got %v", err)
+	}
+	de, _ := xlns.WordsGetWords(dir, "de")
+	// Fix a translation by hand, change one line and add another.
+	de[0] = "Von Hand"
+	xlns.WordsWriteWords(dir, "de", de)
+	en, _ := xlns.WordsGetWords(dir, "en")
+	en[1] = "changed"
+	en = append(en, "added")
+	xlns.WordsWriteWords(dir, "en", en)
+	ft = &fakeTranslator{}
+	err = xlns.XlnsUpdateTranslator(dir, ft, "en", nil)
+	if err != nil {
+		t.Fatalf("XlnsUpdateTranslator got %v", err)
+	}
+	de, _ = xlns.WordsGetWords(dir, "de")
+	if de[0] != "Von Hand" {
+		t.Errorf("hand fixed line got %q", de[0])
+	}
+	if de[1] != "de: changed" || de[len(de)-1] != "de: added" {
+		t.Errorf("changed lines got %q and %q", de[1], de[len(de)-1])
+	}
+	langs, _ := xlns.WordsLanguages(dir)
+	expected := 2 * (len(langs) - 1)
+	if ft.texts != expected {
+		t.Errorf("translated %d texts expected %d", ft.texts, expected)
+	}
+	// Now everything is up to date.
+	ft = &fakeTranslator{}
+	err = xlns.XlnsUpdateTranslator(dir, ft, "en", nil)
+	if err != nil {
+		t.Fatalf("XlnsUpdateTranslator got %v", err)
+	}
+	if ft.calls != 0 {
+		t.Errorf("up to date update made %d calls", ft.calls)
+	}
+}
+
+// flakyTranslator fails the first call for each language.
+type flakyTranslator struct {
+	fakeTranslator
+	mu     sync.Mutex
+	failed map[string]bool
+	err    error
+}
+
+func (ft *flakyTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	if !ft.failed[target] {
+		ft.failed[target] = true
+		return nil, ft.err
+	}
+	return ft.fakeTranslator.Translate(ctx, source, target, texts)
+}
+
+func TestXlnsRetries(t *testing.T) {
+	dir := copyWords(t)
+	ft := &flakyTranslator{
+		failed: make(map[string]bool),
+		err:    &xlns.RetryableError{Err: errors.New("quota")},
+	}
+	options := &xlns.XlnsOptions{
+		Workers: 4,
+		Retries: 2,
+		Backoff: time.Millisecond,
+	}
+	err := xlns.XlnsUpdateTranslator(dir, ft, "en", options)
+	if err != nil {
+		t.Fatalf("XlnsUpdateTranslator got %v", err)
+	}
+	words, _ := xlns.WordsGetWords(dir, "zu")
+	if words[1] != "zu: tests" {
+		t.Errorf("zu not updated got %q", words[1])
+	}
+	// A retried batch is charged to the budget once.
+	en, _ := xlns.WordsGetWords(dir, "en")
+	chars := 0
+	for _, word := range en {
+		chars += utf8.RuneCountInString(word)
+	}
+	ft = &flakyTranslator{
+		failed: make(map[string]bool),
+		err:    &xlns.RetryableError{Err: errors.New("quota")},
+	}
+	err = xlns.XlnsAddTranslator(dir, ft, "en", []string{"de"},
+		&xlns.XlnsOptions{Retries: 1, Backoff: time.Millisecond, Budget: chars})
+	if err != nil {
+		t.Errorf("retried XlnsAddTranslator within budget got %v", err)
+	}
+	// Errors that are not retryable stop the run.
+	ft = &flakyTranslator{
+		failed: make(map[string]bool),
+		err:    errors.New("bad"),
+	}
+	err = xlns.XlnsAddTranslator(dir, ft, "en", []string{"de", "pl"}, options)
+	if err == nil || xlns.IsRetryable(err) {
+		t.Errorf("XlnsAddTranslator got %v expected bad", err)
+	}
+}
+
+// cancelTranslator cancels the run when asked to translate into lang.
+type cancelTranslator struct {
+	fakeTranslator
+	lang   string
+	cancel context.CancelFunc
+}
+
+func (ct *cancelTranslator) Translate(ctx context.Context, source, target string, texts []string) ([]string, error) {
+	if target == ct.lang {
+		ct.cancel()
+		return nil, ctx.Err()
+	}
+	return ct.fakeTranslator.Translate(ctx, source, target, texts)
+}
+
+func TestXlnsUpdateContext(t *testing.T) {
+	dir := copyWords(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ct := &cancelTranslator{lang: "fr", cancel: cancel}
+	err := xlns.XlnsUpdateContext(ctx, dir, ct, "en", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("XlnsUpdateContext got %v expected cancel", err)
+	}
+	// Languages are done in order, those before fr were written.
+	fi, _ := xlns.WordsGetWords(dir, "fi")
+	if fi[1] != "fi: tests" {
+		t.Errorf("fi not updated got %q", fi[1])
+	}
+	for _, lang := range []string{"fr", "ja"} {
+		words, _ := xlns.WordsGetWords(dir, lang)
+		if words[1] == lang+": tests" {
+			t.Errorf("%s updated after cancel", lang)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// translator.go
+// The Translator interface implemented by the translation backends.
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+)
+
+// Translator is a translation backend.  XlnsAddTranslator,
+// XlnsUpdateTranslator and XlnsSupportedTranslator do all of their
+// translating through a Translator so the engine can be swapped, or faked
+// in tests.
+type Translator interface {
+	// Translate translates texts from the source language to the target
+	// language.  It returns one translation per text in the same order.
+	Translate(ctx context.Context, source, target string, texts []string) ([]string, error)
+	// Supported returns the languages the backend supports with their
+	// names in displayLang.
+	Supported(ctx context.Context, displayLang string) ([]Language, error)
+	// Detect returns the most likely language for each of texts.
+	Detect(ctx context.Context, texts []string) ([]Detection, error)
+	// Close releases any resources held by the backend.
+	Close() error
+}
+
+// Language is a language supported by a Translator.
+type Language struct {
+	Code string // BCP-47 code.
+	Name string // Name in the display language.
+}
+
+// Detection is the result of detecting the language of a text.
+type Detection struct {
+	Code       string  // BCP-47 code.
+	Confidence float32 // 0 to 1, 0 if the backend does not say.
+}
+
+// EngineNamer is implemented by Translators that can name their engine and
+// model.  The names are part of the key for cached translations.
+type EngineNamer interface {
+	EngineName() (engine, model string)
+}
+
+// TranslatorEngine returns the engine and model names for translator.
+func TranslatorEngine(translator Translator) (engine, model string) {
+	if namer, ok := translator.(EngineNamer); ok {
+		return namer.EngineName()
+	}
+	return fmt.Sprintf("%T", translator), ""
+}
+
+// TargetEngineNamer is implemented by Translators whose engine or model
+// depends on the target language.
+type TargetEngineNamer interface {
+	TargetEngineName(target string) (engine, model string)
+}
+
+// TranslatorTargetEngine returns the engine and model names translator
+// uses for target.
+func TranslatorTargetEngine(translator Translator, target string) (engine, model string) {
+	if namer, ok := translator.(TargetEngineNamer); ok {
+		return namer.TargetEngineName(target)
+	}
+	return TranslatorEngine(translator)
+}
+
+// BatchLimits are the most a Translator accepts in one Translate call.
+// Zero means no limit.
+type BatchLimits struct {
+	Segments   int // Number of texts.
+	CodePoints int // Total code points of the texts.
+	Bytes      int // Total bytes of the texts form encoded as text=...&.
+}
+
+// BatchLimiter is implemented by Translators with request size limits.
+// Texts are split into batches within the limits before translating.
+type BatchLimiter interface {
+	BatchLimits() BatchLimits
+}
+
+// TranslatorBatchLimits returns the batch limits of translator.
+func TranslatorBatchLimits(translator Translator) BatchLimits {
+	if limiter, ok := translator.(BatchLimiter); ok {
+		return limiter.BatchLimits()
+	}
+	return BatchLimits{}
+}
+
+// DetectBatchLimiter is implemented by Translators whose Detect calls have
+// other limits than their Translate calls.
+type DetectBatchLimiter interface {
+	DetectBatchLimits() BatchLimits
+}
+
+// TranslatorDetectBatchLimits returns the batch limits of translator's
+// Detect calls, its BatchLimits unless it has others.
+func TranslatorDetectBatchLimits(translator Translator) BatchLimits {
+	if limiter, ok := translator.(DetectBatchLimiter); ok {
+		return limiter.DetectBatchLimits()
+	}
+	return TranslatorBatchLimits(translator)
+}
+
+// Batches splits texts into consecutive batches within limits.  It
+// returns the end index of each batch.  A text on its own over the code
+// point or byte limit gets a batch to itself.
+func (bl BatchLimits) Batches(texts []string) []int {
+	var ends []int
+	segments, codePoints, bytes := 0, 0, 0
+	for i, text := range texts {
+		n := utf8.RuneCountInString(text)
+		b := 0
+		if bl.Bytes > 0 {
+			b = len("text=") + len(url.QueryEscape(text)) + len("&")
+		}
+		full := bl.Segments > 0 && segments+1 > bl.Segments
+		full = full || bl.CodePoints > 0 && codePoints+n > bl.CodePoints
+		full = full || bl.Bytes > 0 && bytes+b > bl.Bytes
+		if full && segments > 0 {
+			ends = append(ends, i)
+			segments, codePoints, bytes = 0, 0, 0
+		}
+		segments++
+		codePoints += n
+		bytes += b
+	}
+	if segments > 0 {
+		ends = append(ends, len(texts))
+	}
+	return ends
+}
+
+// GlossaryApplier is implemented by Translators that can apply a glossary
+// themselves, such as a GoogleTranslator with a glossary set.  Otherwise
+// glossary terms are enforced by masking them before translating.
+type GlossaryApplier interface {
+	AppliesGlossary(source, target string) bool
+}
+
+// TranslatorAppliesGlossary returns whether translator applies its own
+// glossary from source to target.
+func TranslatorAppliesGlossary(translator Translator, source, target string) bool {
+	if applier, ok := translator.(GlossaryApplier); ok {
+		return applier.AppliesGlossary(source, target)
+	}
+	return false
+}
+
+// NotesTranslator is implemented by Translators that can use a note on
+// each text, such as "verb, a menu item", to pick the right translation.
+type NotesTranslator interface {
+	// TranslateNotes is Translate with notes[i], "" for none, on texts[i].
+	TranslateNotes(ctx context.Context, source, target string, texts, notes []string) ([]string, error)
+}
+
+// RetryableError marks a Translator error that may succeed if retried
+// later, such as running over a quota or the service being unavailable.
+type RetryableError struct {
+	Err error
+}
+
+func (re *RetryableError) Error() string {
+	return re.Err.Error()
+}
+
+func (re *RetryableError) Unwrap() error {
+	return re.Err
+}
+
+// IsRetryable returns whether err is, or wraps, a RetryableError.
+func IsRetryable(err error) bool {
+	var re *RetryableError
+	return errors.As(err, &re)
+}
@@ -0,0 +1,179 @@
+// wordstxn.go
+// Staged changes and locking for words directories.
+package translate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	LOCK_FILENAME = ".translate.lock"
+	STAGE_PREFIX  = ".translate-stage-"
+)
+
+// WordsTxn stages changes to the files of a words directory so they are
+// all made together when committed, or not at all.  New files are written
+// to a temporary directory inside the words directory and renamed into
+// place by Commit.  It is safe for concurrent use.
+type WordsTxn struct {
+	wordsDir string
+	stageDir string
+	mu       sync.Mutex
+	staged   map[string]bool // Names of files to move into wordsDir.
+	removed  map[string]bool // Names of files to remove from wordsDir.
+	done     bool
+}
+
+// WordsBegin starts staging changes to wordsDir.  Call Abort when done,
+// it does nothing after Commit.
+func WordsBegin(wordsDir string) (*WordsTxn, error) {
+	stageDir, err := ioutil.TempDir(wordsDir, STAGE_PREFIX)
+	if err != nil {
+		return nil, fmt.Errorf("staging got %v", err)
+	}
+	return &WordsTxn{
+		wordsDir: wordsDir,
+		stageDir: stageDir,
+		staged:   make(map[string]bool),
+		removed:  make(map[string]bool),
+	}, nil
+}
+
+// WriteWords stages the words for a language.
+func (wt *WordsTxn) WriteWords(lang string, words []string) error {
+	return wt.write(lang+WORDS_SUFFIX, words)
+}
+
+// WriteFingerprints stages the fingerprints for a language.
+func (wt *WordsTxn) WriteFingerprints(lang string, fingerprints []string) error {
+	return wt.write(lang+FINGERPRINTS_SUFFIX, fingerprints)
+}
+
+// RemoveFingerprints stages removing the fingerprints for a language.
+func (wt *WordsTxn) RemoveFingerprints(lang string) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	name := lang + FINGERPRINTS_SUFFIX
+	delete(wt.staged, name)
+	wt.removed[name] = true
+}
+
+func (wt *WordsTxn) write(name string, lines []string) error {
+	err := writeLines(path.Join(wt.stageDir, name), lines)
+	if err != nil {
+		return err
+	}
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	delete(wt.removed, name)
+	wt.staged[name] = true
+	return nil
+}
+
+// Commit moves the staged files into the words directory and removes the
+// files staged for removal.  The files replaced or removed are first
+// moved aside so if any step fails they are put back, leaving the words
+// directory as it was.
+func (wt *WordsTxn) Commit() error {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	if wt.done {
+		return fmt.Errorf("staged changes already finished")
+	}
+	wt.done = true
+	defer os.RemoveAll(wt.stageDir)
+	// The files replaced are kept with the staged ones until done.
+	backupDir := path.Join(wt.stageDir, ".backup")
+	err := os.Mkdir(backupDir, 0755)
+	if err != nil {
+		return fmt.Errorf("committing got %v", err)
+	}
+	names := make([]string, 0, len(wt.staged)+len(wt.removed))
+	for name := range wt.staged {
+		names = append(names, name)
+	}
+	for name := range wt.removed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var changed []string
+	backedUp := make(map[string]bool)
+	for _, name := range names {
+		target := path.Join(wt.wordsDir, name)
+		err = os.Rename(target, path.Join(backupDir, name))
+		if err == nil {
+			backedUp[name] = true
+		} else if !os.IsNotExist(err) {
+			wt.rollback(backupDir, changed, backedUp)
+			return fmt.Errorf("committing %s got %v", name, err)
+		}
+		changed = append(changed, name)
+		if wt.staged[name] {
+			err = os.Rename(path.Join(wt.stageDir, name), target)
+			if err != nil {
+				wt.rollback(backupDir, changed, backedUp)
+				return fmt.Errorf("committing %s got %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// rollback puts back the files of the words directory changed by Commit,
+// newest first, from backupDir.
+func (wt *WordsTxn) rollback(backupDir string, changed []string, backedUp map[string]bool) {
+	for i := len(changed) - 1; i >= 0; i-- {
+		name := changed[i]
+		target := path.Join(wt.wordsDir, name)
+		if backedUp[name] {
+			os.Rename(path.Join(backupDir, name), target)
+		} else {
+			os.Remove(target)
+		}
+	}
+}
+
+// Abort throws away the staged changes.
+func (wt *WordsTxn) Abort() error {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	if wt.done {
+		return nil
+	}
+	wt.done = true
+	return os.RemoveAll(wt.stageDir)
+}
+
+// WordsLock takes the advisory lock on wordsDir so only one process
+// writes it at a time.  Call the returned function to release it.  A lock
+// left behind by a process that died has to be removed by hand.
+func WordsLock(wordsDir string) (func() error, error) {
+	filename := path.Join(wordsDir, LOCK_FILENAME)
+	w, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		owner, _ := ioutil.ReadFile(filename)
+		return nil, fmt.Errorf("%s is locked by process %s, remove %s if it is not running",
+			wordsDir, strings.TrimSpace(string(owner)), filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("locking %s got %v", wordsDir, err)
+	}
+	_, err = fmt.Fprintf(w, "%d\n", os.Getpid())
+	closeErr := w.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename)
+		return nil, fmt.Errorf("locking %s got %v", wordsDir, err)
+	}
+	return func() error {
+		return os.Remove(filename)
+	}, nil
+}
@@ -1,153 +1,278 @@
-// xlns.go
-// Google Cloud API translate functions for words files.
-package translate
-
-import (
-	"context"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-
-	tr "cloud.google.com/go/translate/apiv3"
-	"google.golang.org/api/option"
-	trpb "google.golang.org/genproto/googleapis/cloud/translate/v3"
-)
-
-const (
-	PROJECT_ID = "project_id"
-)
-
-// XlnsAdd adds new languages to a meaning ordered words directory.
-func XlnsAdd(wordsDir, credentialsJson, mainLang string, newLangs []string) error {
-	words, err := WordsGetWords(wordsDir, mainLang)
-	if err != nil {
-		return err
-	}
-	ctx := context.Background()
-	option := option.WithCredentialsFile(credentialsJson)
-	client, err := tr.NewTranslationClient(ctx, option)
-	if err != nil {
-		return fmt.Errorf("new client got %v", err)
-	}
-	defer client.Close()
-	parent, err := parent(credentialsJson)
-	if err != nil {
-		return err
-	}
-	for _, newLang := range newLangs {
-		req := &trpb.TranslateTextRequest{
-			Parent:             parent,
-			SourceLanguageCode: mainLang,
-			TargetLanguageCode: newLang,
-			MimeType:           "text/plain", // Mime type plain or html
-			Contents:           words,
-		}
-		resp, err := client.TranslateText(ctx, req)
-		if err != nil {
-			return fmt.Errorf("translate text got  %v", err)
-		}
-		translated := make([]string, len(words))
-		for i, translation := range resp.GetTranslations() {
-			translated[i] = translation.GetTranslatedText()
-		}
-		err = WordsWriteWords(wordsDir, newLang, translated)
-		if err != nil {
-			return fmt.Errorf("writing words for %s", newLang)
-		}
-	}
-	return nil
-}
-
-// XlnsSupported outputs the list of Google Cloud Translate API supported
-// languages.
-func XlnsSupported(credentialsJson, lang string) error {
-	ctx := context.Background()
-	option := option.WithCredentialsFile(credentialsJson)
-	client, err := tr.NewTranslationClient(ctx, option)
-	if err != nil {
-		return fmt.Errorf("new client got %v", err)
-	}
-	defer client.Close()
-	parent, err := parent(credentialsJson)
-	if err != nil {
-		return err
-	}
-	req := &trpb.GetSupportedLanguagesRequest{
-		Parent:              parent,
-		DisplayLanguageCode: lang}
-	langs, err := client.GetSupportedLanguages(ctx, req)
-	if err != nil {
-		return fmt.Errorf("supported languages got %v", err)
-	}
-	for _, lang := range langs.Languages {
-		fmt.Printf("\t%s %s\n", lang.LanguageCode, lang.DisplayName)
-	}
-	return nil
-}
-
-// parent returns the "parent" needed for some API calls.
-func parent(credentialsJson string) (string, error) {
-	credentials, err := ioutil.ReadFile(credentialsJson)
-	if err != nil {
-		return "", fmt.Errorf("reading credentials got %v", err)
-	}
-	data := make(map[string]string)
-	err = json.Unmarshal(credentials, &data)
-	if err != nil {
-		return "", fmt.Errorf("unpacking credentials got %v", err)
-	}
-	id, ok := data[PROJECT_ID]
-	if !ok {
-		return "", fmt.Errorf("no %s in credentials", PROJECT_ID)
-	}
-	return fmt.Sprintf("projects/%s/locations/global", id), nil
-}
-
-// XlnsUpdate updates all meaning ordered words files based by translating
-// from mainLang.  It retranslates the whole file.
-func XlnsUpdate(wordsDir, credentialsJson, mainLang string) error {
-	words, err := WordsGetWords(wordsDir, mainLang)
-	if err != nil {
-		return err
-	}
-	ctx := context.Background()
-	option := option.WithCredentialsFile(credentialsJson)
-	client, err := tr.NewTranslationClient(ctx, option)
-	if err != nil {
-		return fmt.Errorf("new client got %v", err)
-	}
-	defer client.Close()
-	parent, err := parent(credentialsJson)
-	if err != nil {
-		return err
-	}
-	langs, err := WordsLanguages(wordsDir)
-	if err != nil {
-		return err
-	}
-	for _, lang := range langs {
-		if lang == mainLang {
-			continue
-		}
-		req := &trpb.TranslateTextRequest{
-			Parent:             parent,
-			SourceLanguageCode: mainLang,
-			TargetLanguageCode: lang,
-			MimeType:           "text/plain", // Mime type plain or html
-			Contents:           words,
-		}
-		resp, err := client.TranslateText(ctx, req)
-		if err != nil {
-			return fmt.Errorf("translate text got  %v", err)
-		}
-		translated := make([]string, len(words))
-		for i, translation := range resp.GetTranslations() {
-			translated[i] = translation.GetTranslatedText()
-		}
-		err = WordsWriteWords(wordsDir, lang, translated)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
+// xlns.go
+// Translate functions for words files.
+package translate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// XlnsOptions are the optional settings for XlnsAddTranslator and
+// XlnsUpdateTranslator.  A nil *XlnsOptions uses the defaults.
+type XlnsOptions struct {
+	// Full retranslates every line on update instead of only the new or
+	// changed ones.
+	Full bool
+	// Cache, if not nil, is consulted before calling the Translator and
+	// filled with its results.
+	Cache *XlnsCache
+	// Workers is how many languages are translated at once.  Less than 1
+	// means 1.
+	Workers int
+	// RequestsPerSecond and CharactersPerMinute limit the calls to the
+	// Translator across all workers.  0 means no limit.
+	RequestsPerSecond   float64
+	CharactersPerMinute int
+	// Retries is how many times a call failing with a RetryableError is
+	// retried.  The wait before each retry starts at Backoff, 1 second if
+	// 0, and doubles each time up to a minute.
+	Retries int
+	Backoff time.Duration
+	// Budget is the most characters sent to the Translator in the run.  A
+	// request that would go over it is not sent and the run fails with a
+	// BudgetError.  0 means no limit.
+	Budget int
+	// Protect masks placeholders, such as %s, {name} and $(NAME), and
+	// markup in the texts sent to the Translator and restores them in the
+	// translations.  Translations whose placeholders differ from their
+	// source fail the run with StrictPlaceholders or are passed to Warn.
+	Protect            bool
+	StrictPlaceholders bool
+	// Glossary, if not nil, fixes how its terms are translated.  Unless
+	// the Translator applies a glossary itself, the terms are masked in
+	// the texts sent and replaced by their translations afterwards.
+	Glossary *Glossary
+	// Notes, if not nil, are the meaning ordered notes on the mainLang
+	// lines, as from WordsGetNotes, given to a NotesTranslator with each
+	// line.  Other Translators do not use them.
+	Notes []string
+	// Warn, if not nil, is given problems found with translations.
+	Warn func(WordsProblem)
+	// AllOrNothing writes the languages only if every one of them is
+	// translated.  Otherwise each language is written once translated.
+	AllOrNothing bool
+	// Resume continues an unfinished run, of the same command on the same
+	// mainLang words, skipping the languages and batches its journal
+	// records as done.
+	Resume bool
+}
+
+// XlnsAdd adds new languages to a meaning ordered words directory using
+// Google Cloud Translate with credentialsJson.
+func XlnsAdd(wordsDir, credentialsJson, mainLang string, newLangs []string) error {
+	ctx := context.Background()
+	translator, err := NewGoogleTranslator(ctx, credentialsJson)
+	if err != nil {
+		return err
+	}
+	defer translator.Close()
+	return XlnsAddContext(ctx, wordsDir, translator, mainLang, newLangs, nil)
+}
+
+// XlnsAddTranslator adds new languages to a meaning ordered words
+// directory using translator.
+func XlnsAddTranslator(wordsDir string, translator Translator, mainLang string, newLangs []string, options *XlnsOptions) error {
+	return XlnsAddContext(context.Background(), wordsDir, translator, mainLang, newLangs, options)
+}
+
+// XlnsAddContext is XlnsAddTranslator with a context.  If ctx is
+// cancelled the languages already translated are written and the rest are
+// untouched.
+func XlnsAddContext(ctx context.Context, wordsDir string, translator Translator, mainLang string, newLangs []string, options *XlnsOptions) error {
+	unlock, err := WordsLock(wordsDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	words, jobs, err := xlnsAddJobs(wordsDir, mainLang, newLangs)
+	if err != nil {
+		return err
+	}
+	xr := newXlnsRunner(wordsDir, translator, mainLang, words, options)
+	return xr.run(ctx, "add", jobs)
+}
+
+// XlnsSupported outputs the list of Google Cloud Translate API supported
+// languages.
+func XlnsSupported(credentialsJson, lang string) error {
+	ctx := context.Background()
+	translator, err := NewGoogleTranslator(ctx, credentialsJson)
+	if err != nil {
+		return err
+	}
+	defer translator.Close()
+	return XlnsSupportedContext(ctx, translator, lang)
+}
+
+// XlnsSupportedTranslator outputs the list of languages supported by
+// translator.
+func XlnsSupportedTranslator(translator Translator, lang string) error {
+	return XlnsSupportedContext(context.Background(), translator, lang)
+}
+
+// XlnsSupportedContext is XlnsSupportedTranslator with a context.
+func XlnsSupportedContext(ctx context.Context, translator Translator, lang string) error {
+	langs, err := translator.Supported(ctx, lang)
+	if err != nil {
+		return err
+	}
+	for _, lang := range langs {
+		fmt.Printf("\t%s %s\n", lang.Code, lang.Name)
+	}
+	return nil
+}
+
+// XlnsUpdate updates all meaning ordered words files based by translating
+// from mainLang using Google Cloud Translate with credentialsJson.  It
+// retranslates the whole file.
+func XlnsUpdate(wordsDir, credentialsJson, mainLang string) error {
+	ctx := context.Background()
+	translator, err := NewGoogleTranslator(ctx, credentialsJson)
+	if err != nil {
+		return err
+	}
+	defer translator.Close()
+	return XlnsUpdateContext(ctx, wordsDir, translator, mainLang, &XlnsOptions{Full: true})
+}
+
+// XlnsUpdateTranslator updates all meaning ordered words files based by
+// translating from mainLang using translator.  Only lines that are new or
+// have changed since the last translation are translated, unless
+// options.Full is set.  Lines already translated, including any fixed by
+// hand, are kept.
+func XlnsUpdateTranslator(wordsDir string, translator Translator, mainLang string, options *XlnsOptions) error {
+	return XlnsUpdateContext(context.Background(), wordsDir, translator, mainLang, options)
+}
+
+// XlnsUpdateContext is XlnsUpdateTranslator with a context.  If ctx is
+// cancelled the languages already translated are written and the rest are
+// untouched.
+func XlnsUpdateContext(ctx context.Context, wordsDir string, translator Translator, mainLang string, options *XlnsOptions) error {
+	unlock, err := WordsLock(wordsDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	full := options != nil && options.Full
+	words, jobs, err := xlnsUpdateJobs(wordsDir, mainLang, full)
+	if err != nil {
+		return err
+	}
+	xr := newXlnsRunner(wordsDir, translator, mainLang, words, options)
+	return xr.run(ctx, "update", jobs)
+}
+
+// xlnsJob is the work needed to bring one language up to date.
+type xlnsJob struct {
+	lang  string
+	lines []string // The translations in meaning order.
+	todo  []int    // The indexes of lines needing translation.
+}
+
+// xlnsAddJobs returns the mainLang words and the jobs to add newLangs.
+func xlnsAddJobs(wordsDir, mainLang string, newLangs []string) ([]string, []*xlnsJob, error) {
+	words, err := WordsGetWords(wordsDir, mainLang)
+	if err != nil {
+		return nil, nil, err
+	}
+	jobs := make([]*xlnsJob, len(newLangs))
+	for i, newLang := range newLangs {
+		jobs[i] = xlnsFull(newLang, words)
+	}
+	return words, jobs, nil
+}
+
+// xlnsUpdateJobs returns the mainLang words and the jobs to update the
+// other languages in wordsDir.
+func xlnsUpdateJobs(wordsDir, mainLang string, full bool) ([]string, []*xlnsJob, error) {
+	words, err := WordsGetWords(wordsDir, mainLang)
+	if err != nil {
+		return nil, nil, err
+	}
+	langs, err := WordsLanguages(wordsDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var jobs []*xlnsJob
+	for _, lang := range langs {
+		if lang == mainLang {
+			continue
+		}
+		job, err := xlnsPlan(wordsDir, mainLang, lang, words, full)
+		if err != nil {
+			return nil, nil, err
+		}
+		if job != nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return words, jobs, nil
+}
+
+// xlnsPlan works out which of the mainLang words need translating into
+// lang, keeping the existing translations that are still up to date.  It
+// returns nil if lang needs no changes at all.
+func xlnsPlan(wordsDir, mainLang, lang string, words []string, full bool) (*xlnsJob, error) {
+	if full {
+		return xlnsFull(lang, words), nil
+	}
+	job := &xlnsJob{lang: lang, lines: make([]string, len(words))}
+	known := make(map[string]string)
+	fingerprints, err := WordsGetFingerprints(wordsDir, lang)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := WordsGetWords(wordsDir, lang)
+	if err != nil {
+		return nil, err
+	}
+	upToDate := false
+	// Missing or stale fingerprints mean translating everything.
+	if len(fingerprints) == len(existing) {
+		for i, fingerprint := range fingerprints {
+			known[fingerprint] = existing[i]
+		}
+		upToDate = len(fingerprints) == len(words)
+		for i, word := range words {
+			if upToDate && fingerprints[i] != WordsFingerprint(mainLang, word) {
+				upToDate = false
+			}
+		}
+	}
+	if upToDate {
+		return nil, nil
+	}
+	for i, word := range words {
+		xlns, ok := known[WordsFingerprint(mainLang, word)]
+		if ok {
+			job.lines[i] = xlns
+		} else {
+			job.todo = append(job.todo, i)
+		}
+	}
+	return job, nil
+}
+
+// xlnsFull returns a job translating all of words into lang.
+func xlnsFull(lang string, words []string) *xlnsJob {
+	job := &xlnsJob{
+		lang:  lang,
+		lines: make([]string, len(words)),
+		todo:  make([]int, len(words)),
+	}
+	for i := range words {
+		job.todo[i] = i
+	}
+	return job
+}
+
+// xlnsStage stages the translated words for lang along with the
+// fingerprints of the mainLang words they were translated from.
+func xlnsStage(txn *WordsTxn, mainLang, lang string, words, translated []string) error {
+	err := txn.WriteWords(lang, translated)
+	if err != nil {
+		return fmt.Errorf("writing words for %s got %v", lang, err)
+	}
+	return txn.WriteFingerprints(lang, WordsFingerprints(mainLang, words))
+}
@@ -0,0 +1,563 @@
+// xlnsrun.go
+// Running translation jobs: workers, batching, rate limits and retries.
+package translate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	DEFAULT_BACKOFF = time.Second
+	MAX_BACKOFF     = time.Minute
+)
+
+// xlnsRunner translates and writes the jobs for XlnsAdd and XlnsUpdate.
+type xlnsRunner struct {
+	wordsDir   string
+	translator Translator
+	mainLang   string
+	words      []string
+	options    *XlnsOptions
+	limits     BatchLimits
+	limiter    *xlnsLimiter
+	journal    *xlnsJournal
+	txn        *WordsTxn // Shared by all languages for AllOrNothing.
+	spending   *xlnsSpending
+	mu         sync.Mutex
+	written    map[string]bool // Languages written.
+}
+
+// xlnsSpending counts the characters sent by the runners of one run.
+type xlnsSpending struct {
+	mu    sync.Mutex
+	spent int
+}
+
+func newXlnsRunner(wordsDir string, translator Translator, mainLang string, words []string, options *XlnsOptions) *xlnsRunner {
+	if options == nil {
+		options = &XlnsOptions{}
+	}
+	return &xlnsRunner{
+		wordsDir:   wordsDir,
+		translator: translator,
+		mainLang:   mainLang,
+		words:      words,
+		options:    options,
+		limits:     TranslatorBatchLimits(translator),
+		limiter:    newXlnsLimiter(options.RequestsPerSecond, options.CharactersPerMinute),
+		spending:   &xlnsSpending{},
+		written:    make(map[string]bool),
+	}
+}
+
+// with returns a runner for translator, mainLang and words spending from
+// the same budget as xr, and sharing its rate limits if translator is
+// xr's.
+func (xr *xlnsRunner) with(translator Translator, mainLang string, words []string) *xlnsRunner {
+	other := newXlnsRunner(xr.wordsDir, translator, mainLang, words, xr.options)
+	other.spending = xr.spending
+	if translator == xr.translator {
+		other.limiter = xr.limiter
+	}
+	return other
+}
+
+// run runs the jobs for command using options.Workers workers.  Each
+// language is written as soon as it is translated, or with
+// options.AllOrNothing all are written at the end.  After an error, or
+// parent being cancelled, no new jobs are started and the first error is
+// returned.  Progress is kept in the journal which is removed if the run
+// succeeds.
+func (xr *xlnsRunner) run(parent context.Context, command string, jobs []*xlnsJob) error {
+	langs := make([]string, len(jobs))
+	for i, job := range jobs {
+		langs[i] = job.lang
+	}
+	err := xr.checkNotes()
+	if err != nil {
+		return err
+	}
+	journal, err := startXlnsJournal(xr.wordsDir, command, xr.mainLang, xr.words, langs, xr.options.Resume)
+	if err != nil {
+		return err
+	}
+	xr.journal = journal
+	var todo []*xlnsJob
+	for _, job := range jobs {
+		if journal.resume(job) {
+			todo = append(todo, job)
+		}
+	}
+	if xr.options.AllOrNothing {
+		xr.txn, err = WordsBegin(xr.wordsDir)
+		if err != nil {
+			journal.close(false)
+			return err
+		}
+		defer xr.txn.Abort()
+	}
+	err = xr.runJobs(parent, todo)
+	if err == nil && xr.txn != nil {
+		err = xr.txn.Commit()
+		for _, job := range todo {
+			if err != nil {
+				break
+			}
+			xr.wrote(job.lang)
+			err = journal.finish(job.lang)
+		}
+	}
+	var budgetErr *BudgetError
+	if errors.As(err, &budgetErr) {
+		for _, job := range todo {
+			if !xr.written[job.lang] {
+				budgetErr.Untouched = append(budgetErr.Untouched, job.lang)
+			}
+		}
+	}
+	closeErr := journal.close(err == nil)
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// runJobs runs jobs on the workers.
+func (xr *xlnsRunner) runJobs(parent context.Context, jobs []*xlnsJob) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	workers := xr.options.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	todo := make(chan *xlnsJob)
+	errs := make(chan error, len(jobs))
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range todo {
+				if ctx.Err() != nil {
+					continue // Stopping, leave the rest untouched.
+				}
+				err := xr.job(ctx, job)
+				if err != nil {
+					errs <- err
+					cancel()
+				}
+			}
+		}()
+	}
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		todo <- job
+	}
+	close(todo)
+	wg.Wait()
+	close(errs)
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+	return <-errs
+}
+
+// job translates and writes one language.
+func (xr *xlnsRunner) job(ctx context.Context, job *xlnsJob) error {
+	err := xr.translate(ctx, job)
+	if err != nil {
+		return err
+	}
+	if xr.txn != nil {
+		// Written with the rest when they are all done.
+		return xlnsStage(xr.txn, xr.mainLang, job.lang, xr.words, job.lines)
+	}
+	txn, err := WordsBegin(xr.wordsDir)
+	if err != nil {
+		return err
+	}
+	defer txn.Abort()
+	err = xlnsStage(txn, xr.mainLang, job.lang, xr.words, job.lines)
+	if err != nil {
+		return err
+	}
+	err = txn.Commit()
+	if err != nil {
+		return err
+	}
+	xr.wrote(job.lang)
+	return xr.journal.finish(job.lang)
+}
+
+// wrote records that lang has been written.
+func (xr *xlnsRunner) wrote(lang string) {
+	xr.mu.Lock()
+	defer xr.mu.Unlock()
+	xr.written[lang] = true
+}
+
+// spend accounts for sending chars characters, failing if that would go
+// over options.Budget.
+func (xr *xlnsRunner) spend(chars int) error {
+	xs := xr.spending
+	xs.mu.Lock()
+	defer xs.mu.Unlock()
+	budget := xr.options.Budget
+	if budget > 0 && xs.spent+chars > budget {
+		return &BudgetError{Budget: budget, Spent: xs.spent, Needed: chars}
+	}
+	xs.spent += chars
+	return nil
+}
+
+// translate translates the job.todo lines into job.lines.  Cached
+// translations are used where available and only the rest are sent to the
+// translator, in batches within its limits.  Every batch must come back
+// with one translation per text.
+func (xr *xlnsRunner) translate(ctx context.Context, job *xlnsJob) error {
+	cache := xr.options.Cache
+	lines, send, masked, restore := xr.pending(job)
+	terms := xr.cacheTerms(job.lang)
+	engine, model := TranslatorTargetEngine(xr.translator, job.lang)
+	start := 0
+	for _, end := range xr.limits.Batches(masked) {
+		batch := send[start:end]
+		translated, err := xr.send(ctx, job.lang, masked[start:end], xr.lineNotes(lines[start:end]))
+		if err != nil {
+			return err
+		}
+		if len(translated) != len(batch) {
+			return fmt.Errorf("%s got %d translations for %d texts",
+				job.lang, len(translated), len(batch))
+		}
+		for i := range translated {
+			if len(restore[start+i]) != 0 {
+				translated[i] = UnmaskPlaceholders(translated[i], restore[start+i])
+			}
+			if xr.options.Protect {
+				err = xr.checkPlaceholders(job.lang, lines[start+i], batch[i], translated[i])
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if cache != nil {
+			err = cache.Put(engine, model, xr.mainLang, job.lang,
+				xr.cacheTexts(lines[start:end], terms), translated)
+			if err != nil {
+				return err
+			}
+		}
+		err = xr.journal.batch(job.lang, lines[start:end], translated)
+		if err != nil {
+			return err
+		}
+		for i, xlns := range translated {
+			job.lines[lines[start+i]] = xlns
+		}
+		start = end
+	}
+	return nil
+}
+
+// glossary returns the glossary terms to mask for lang, none if the
+// translator applies the glossary itself.
+func (xr *xlnsRunner) glossary(lang string) []GlossaryPair {
+	if xr.options.Glossary == nil ||
+		TranslatorAppliesGlossary(xr.translator, xr.mainLang, lang) {
+		return nil
+	}
+	return xr.options.Glossary.Pairs(xr.mainLang, lang)
+}
+
+// checkPlaceholders checks the translation of line has the placeholders
+// of its source.  A mismatch fails with options.StrictPlaceholders or is
+// passed to options.Warn.
+func (xr *xlnsRunner) checkPlaceholders(lang string, line int, source, translation string) error {
+	if PlaceholdersMatch(source, translation) {
+		return nil
+	}
+	problem := WordsProblem{
+		Lang: lang,
+		Line: line + 1,
+		Message: fmt.Sprintf("placeholders %q expected %q",
+			Placeholders(translation), Placeholders(source)),
+	}
+	if xr.options.StrictPlaceholders {
+		return fmt.Errorf("%v", problem)
+	}
+	xr.warn(problem)
+	return nil
+}
+
+// warn passes problem to options.Warn, one at a time.
+func (xr *xlnsRunner) warn(problem WordsProblem) {
+	if xr.options.Warn == nil {
+		return
+	}
+	xr.mu.Lock()
+	defer xr.mu.Unlock()
+	xr.options.Warn(problem)
+}
+
+// pending fills job.lines with cached translations and returns the lines
+// that still need sending to the translator, their texts, the texts
+// masked to send and what to restore in each.
+func (xr *xlnsRunner) pending(job *xlnsJob) ([]int, []string, []string, [][]string) {
+	cache := xr.options.Cache
+	pairs := xr.glossary(job.lang)
+	terms := xr.cacheTerms(job.lang)
+	engine, model := TranslatorTargetEngine(xr.translator, job.lang)
+	var lines []int
+	var send, masked []string
+	var restore [][]string
+	for _, line := range job.todo {
+		text := xr.words[line]
+		mask, tokens := xr.mask(text, pairs)
+		if cache != nil {
+			xlns, ok := cache.Get(engine, model, xr.mainLang, job.lang, xr.cacheText(line, terms))
+			if ok {
+				job.lines[line] = xlns
+				continue
+			}
+		}
+		lines = append(lines, line)
+		send = append(send, text)
+		masked = append(masked, mask)
+		restore = append(restore, tokens)
+	}
+	return lines, send, masked, restore
+}
+
+// mask masks the placeholders in text, with options.Protect, and the
+// glossary pairs.  It returns the masked text and what to restore.
+func (xr *xlnsRunner) mask(text string, pairs []GlossaryPair) (string, []string) {
+	var restore []string
+	if xr.options.Protect {
+		text, restore = MaskPlaceholders(text)
+	}
+	return maskGlossary(text, pairs, restore)
+}
+
+// notes returns whether the lines have notes the translator uses.
+func (xr *xlnsRunner) notes() bool {
+	_, ok := xr.translator.(NotesTranslator)
+	return ok && xr.options.Notes != nil
+}
+
+// checkNotes checks there is a note for every line if the translator uses
+// them.
+func (xr *xlnsRunner) checkNotes() error {
+	if xr.notes() && len(xr.options.Notes) != len(xr.words) {
+		return fmt.Errorf("%d notes for %d %s lines",
+			len(xr.options.Notes), len(xr.words), xr.mainLang)
+	}
+	return nil
+}
+
+// lineNotes returns the notes for lines, nil if there are none to send.
+func (xr *xlnsRunner) lineNotes(lines []int) []string {
+	if !xr.notes() {
+		return nil
+	}
+	notes := make([]string, len(lines))
+	for i, line := range lines {
+		notes[i] = xr.note(line)
+	}
+	return notes
+}
+
+// note returns the note on line, "" for none.
+func (xr *xlnsRunner) note(line int) string {
+	if !xr.notes() || line >= len(xr.options.Notes) {
+		return ""
+	}
+	return xr.options.Notes[line]
+}
+
+// cacheTerms returns the glossary terms that change translations into
+// lang, whether masked or applied by the translator.
+func (xr *xlnsRunner) cacheTerms(lang string) []GlossaryPair {
+	if xr.options.Glossary == nil {
+		return nil
+	}
+	return xr.options.Glossary.Pairs(xr.mainLang, lang)
+}
+
+// cacheText returns the cached text for line.  A note, glossary terms and
+// protected placeholders change the translation so they are part of the
+// text.
+func (xr *xlnsRunner) cacheText(line int, terms []GlossaryPair) string {
+	text := xr.words[line]
+	note := xr.note(line)
+	if masked, restore := xr.mask(text, terms); len(restore) != 0 {
+		sum := sha256.Sum256([]byte(masked + "\x00" + strings.Join(restore, "\x00")))
+		return text + "\x00" + note + "\x00" + hex.EncodeToString(sum[:8])
+	}
+	if note != "" {
+		return text + "\x00" + note
+	}
+	return text
+}
+
+// cacheTexts returns the cached texts for lines.
+func (xr *xlnsRunner) cacheTexts(lines []int, terms []GlossaryPair) []string {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = xr.cacheText(line, terms)
+	}
+	return texts
+}
+
+// send sends one batch, with its notes if not nil, to the translator,
+// within the budget and rate limits and retrying retryable errors.
+func (xr *xlnsRunner) send(ctx context.Context, lang string, batch, notes []string) ([]string, error) {
+	var translated []string
+	err := xr.call(ctx, batch, func() error {
+		var err error
+		if notes != nil {
+			translated, err = xr.translator.(NotesTranslator).TranslateNotes(ctx, xr.mainLang, lang, batch, notes)
+		} else {
+			translated, err = xr.translator.Translate(ctx, xr.mainLang, lang, batch)
+		}
+		return err
+	})
+	return translated, err
+}
+
+// call calls fn to send batch to the translator, within the budget and
+// rate limits and retrying retryable errors.  The batch is charged to the
+// budget once however many times it is retried.
+func (xr *xlnsRunner) call(ctx context.Context, batch []string, fn func() error) error {
+	chars := 0
+	for _, text := range batch {
+		chars += utf8.RuneCountInString(text)
+	}
+	err := xr.spend(chars)
+	if err != nil {
+		return err
+	}
+	backoff := xr.options.Backoff
+	if backoff <= 0 {
+		backoff = DEFAULT_BACKOFF
+	}
+	for retry := 0; ; retry++ {
+		err = xr.limiter.wait(ctx, chars)
+		if err != nil {
+			return err
+		}
+		err = fn()
+		if err == nil || !IsRetryable(err) || retry >= xr.options.Retries {
+			return err
+		}
+		err = sleep(ctx, backoff)
+		if err != nil {
+			return err
+		}
+		backoff *= 2
+		if backoff > MAX_BACKOFF {
+			backoff = MAX_BACKOFF
+		}
+	}
+}
+
+// BudgetError is returned when sending a request would go over the
+// character budget of a run.
+type BudgetError struct {
+	Budget    int      // The characters allowed.
+	Spent     int      // The characters already sent.
+	Needed    int      // The characters in the request not sent.
+	Untouched []string // The languages left unchanged.
+}
+
+func (be *BudgetError) Error() string {
+	return fmt.Sprintf("over budget of %d characters with %d spent and %d more needed, %s left untouched",
+		be.Budget, be.Spent, be.Needed, strings.Join(be.Untouched, " "))
+}
+
+// sleep for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// xlnsLimiter limits the rate of requests and of characters sent.
+type xlnsLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration // Between requests, 0 for no limit.
+	next      time.Time     // Earliest time for the next request.
+	perMinute int           // Characters per minute, 0 for no limit.
+	sent      []xlnsSent    // Sent in the last minute.
+}
+
+type xlnsSent struct {
+	at    time.Time
+	chars int
+}
+
+func newXlnsLimiter(requestsPerSecond float64, charactersPerMinute int) *xlnsLimiter {
+	xl := &xlnsLimiter{perMinute: charactersPerMinute}
+	if requestsPerSecond > 0 {
+		xl.interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return xl
+}
+
+// wait until a request of chars characters can be sent.
+func (xl *xlnsLimiter) wait(ctx context.Context, chars int) error {
+	for {
+		delay := xl.reserve(chars)
+		if delay == 0 {
+			return nil
+		}
+		err := sleep(ctx, delay)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// reserve reserves a request of chars characters, returning 0, or returns
+// how long to wait before trying again.
+func (xl *xlnsLimiter) reserve(chars int) time.Duration {
+	xl.mu.Lock()
+	defer xl.mu.Unlock()
+	now := time.Now()
+	if now.Before(xl.next) {
+		return xl.next.Sub(now)
+	}
+	if xl.perMinute > 0 {
+		for len(xl.sent) > 0 && now.Sub(xl.sent[0].at) >= time.Minute {
+			xl.sent = xl.sent[1:]
+		}
+		total := 0
+		for _, sent := range xl.sent {
+			total += sent.chars
+		}
+		// A request over the limit on its own goes when nothing else has.
+		if total > 0 && total+chars > xl.perMinute {
+			return xl.sent[0].at.Add(time.Minute).Sub(now)
+		}
+		xl.sent = append(xl.sent, xlnsSent{at: now, chars: chars})
+	}
+	if xl.interval > 0 {
+		xl.next = now.Add(xl.interval)
+	}
+	return 0
+}